@@ -1,12 +1,10 @@
 package cmd
 
 import (
-	"bufio"
 	"fmt"
-	"os"
-	"strings"
 
 	"github.com/fishfisher/apple-notes/internal/applescript"
+	"github.com/fishfisher/apple-notes/internal/db"
 	"github.com/spf13/cobra"
 )
 
@@ -26,16 +24,11 @@ var addCmd = &cobra.Command{
 		// If body not provided via flag, read from stdin or prompt
 		body := addBody
 		if body == "" {
-			fmt.Println("Enter note body (Ctrl+D when done):")
-			scanner := bufio.NewScanner(os.Stdin)
-			var lines []string
-			for scanner.Scan() {
-				lines = append(lines, scanner.Text())
+			var err error
+			body, err = readInput("Enter note body (Ctrl+D when done):")
+			if err != nil {
+				return err
 			}
-			if err := scanner.Err(); err != nil {
-				return fmt.Errorf("failed to read input: %w", err)
-			}
-			body = strings.Join(lines, "\n")
 		}
 
 		// Default to "Notes" folder if not specified
@@ -44,13 +37,29 @@ var addCmd = &cobra.Command{
 			folder = "Notes"
 		}
 
-		fmt.Printf("Creating note '%s' in folder '%s'...\n", title, folder)
-		if err := applescript.AddNote(title, body, folder); err != nil {
+		database, err := db.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		defer database.Close()
+
+		lastID, err := database.MaxObjectID()
+		if err != nil {
+			return err
+		}
+
+		statusf("Creating note '%s' in folder '%s'...\n", title, folder)
+		if err := applescript.AddNote(cmd.Context(), title, body, folder); err != nil {
 			return fmt.Errorf("failed to add note: %w", err)
 		}
 
-		fmt.Println("Note created successfully")
-		return nil
+		note, err := verifyCreated(cmd.Context(), database, title, folder, lastID)
+		if err != nil {
+			return err
+		}
+		note = recordCreate(database, "add", note, title, folder, lastID)
+
+		return reportWrite(noteResult("created", note), "Note created successfully")
 	},
 }
 
@@ -1,13 +1,11 @@
 package cmd
 
 import (
-	"bufio"
 	"fmt"
-	"os"
-	"strings"
 
 	"github.com/fishfisher/apple-notes/internal/applescript"
 	"github.com/fishfisher/apple-notes/internal/db"
+	"github.com/fishfisher/apple-notes/internal/journal"
 	"github.com/spf13/cobra"
 )
 
@@ -28,33 +26,48 @@ var appendCmd = &cobra.Command{
 		}
 		defer database.Close()
 
-		note, err := database.GetNote(noteID)
+		note, err := resolveNoteForWrite(database, noteID)
 		if err != nil {
-			return fmt.Errorf("note not found: %w", err)
+			return err
 		}
 
 		// If content not provided via flag, read from stdin
 		content := appendContent
 		if content == "" {
-			fmt.Println("Enter content to append (Ctrl+D when done):")
-			scanner := bufio.NewScanner(os.Stdin)
-			var lines []string
-			for scanner.Scan() {
-				lines = append(lines, scanner.Text())
+			content, err = readInput("Enter content to append (Ctrl+D when done):")
+			if err != nil {
+				return err
 			}
-			if err := scanner.Err(); err != nil {
-				return fmt.Errorf("failed to read input: %w", err)
-			}
-			content = strings.Join(lines, "\n")
 		}
 
-		fmt.Printf("Appending to note '%s'...\n", note.Title)
-		if err := applescript.AppendNote(note.Title, content); err != nil {
+		before, err := database.ModificationStamp(note.ID)
+		if err != nil {
+			return err
+		}
+
+		scriptID, err := database.AppleScriptID(note.ID)
+		if err != nil {
+			return err
+		}
+
+		entry, err := snapshotNote(cmd.Context(), database, "append", journal.ActionUpdate, note)
+		if err != nil {
+			return err
+		}
+
+		planWrite("append to", *note)
+		statusf("Appending to note '%s'...\n", note.Title)
+		if err := applescript.AppendNoteByID(cmd.Context(), scriptID, content); err != nil {
 			return fmt.Errorf("failed to append to note: %w", err)
 		}
+		recordJournal(entry)
+
+		note, err = verifyModified(cmd.Context(), database, note.ID, before)
+		if err != nil {
+			return err
+		}
 
-		fmt.Println("Content appended successfully")
-		return nil
+		return reportWrite(noteResult("appended", note), "Content appended successfully")
 	},
 }
 
@@ -2,9 +2,10 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 	"time"
 
-	"github.com/fishfisher/apple-notes/internal/applescript"
+	"github.com/fishfisher/apple-notes/internal/archive"
 	"github.com/fishfisher/apple-notes/internal/db"
 	"github.com/spf13/cobra"
 )
@@ -37,7 +38,8 @@ var archiveCmd = &cobra.Command{
 
 		var toArchive []db.Note
 		for _, note := range notes {
-			if note.Modified.Before(cutoffDate) {
+			// Notes already in the archive would lose their original folder
+			if note.Modified.Before(cutoffDate) && note.Folder != folderName(archiveTargetName) {
 				toArchive = append(toArchive, note)
 			}
 		}
@@ -48,25 +50,38 @@ var archiveCmd = &cobra.Command{
 		}
 
 		fmt.Printf("Found %d notes older than %d months\n", len(toArchive), cutoffMonths)
-		fmt.Printf("Move to '%s' folder? (y/N): ", archiveTargetName)
-
-		var response string
-		fmt.Scanln(&response)
-		if response != "y" && response != "Y" {
-			fmt.Println("Archive cancelled")
+		if !confirm(fmt.Sprintf("Move to '%s' folder? (y/N): ", archiveTargetName)) {
+			statusf("Archive cancelled\n")
 			return nil
 		}
 
-		// Move notes
+		// Move notes, remembering where they came from for unarchive
 		moved := 0
+		var records []archive.Record
 		for _, note := range toArchive {
-			if err := applescript.MoveNote(note.Title, archiveTargetName); err != nil {
-				fmt.Printf("Warning: failed to move '%s': %v\n", note.Title, err)
+			origin, err := database.NoteFolderPath(note.ID)
+			if err != nil {
+				return err
+			}
+			record := archive.Record{NoteID: note.ID, Title: note.Title, Folder: origin, Archive: archiveTargetName}
+			if err := moveNoteTo(cmd.Context(), database, "archive", &note, archiveTargetName); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to archive '%s': %v\n", note.Title, err)
+				// A move that landed but was not verified in time still needs
+				// its record, or unarchive cannot bring the note back
+				if current, err := database.GetNote(note.ID); err == nil && current.Folder == folderName(archiveTargetName) {
+					records = append(records, record)
+				}
 				continue
 			}
+			records = append(records, record)
 			moved++
 		}
+		recordArchive(records...)
 
+		if dryRun {
+			fmt.Printf("[dry-run] Would archive %d notes to '%s'\n", moved, archiveTargetName)
+			return nil
+		}
 		fmt.Printf("Successfully archived %d notes to '%s'\n", moved, archiveTargetName)
 		return nil
 	},
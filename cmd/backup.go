@@ -81,25 +81,41 @@ var restoreCmd = &cobra.Command{
 		}
 
 		fmt.Printf("Backup from %s contains %d notes\n", backup.Timestamp.Format("2006-01-02 15:04:05"), len(backup.Notes))
-		fmt.Printf("Restore these notes? (y/N): ")
-
-		var response string
-		fmt.Scanln(&response)
-		if response != "y" && response != "Y" {
-			fmt.Println("Restore cancelled")
+		if !confirm("Restore these notes? (y/N): ") {
+			statusf("Restore cancelled\n")
 			return nil
 		}
 
+		database, err := db.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		defer database.Close()
+
 		restored := 0
 		for i, note := range backup.Notes {
 			fmt.Printf("Restoring %d/%d: %s\n", i+1, len(backup.Notes), note.Title)
-			if err := applescript.AddNote(note.Title, note.Snippet, note.Folder); err != nil {
+			lastID, err := database.MaxObjectID()
+			if err != nil {
+				return err
+			}
+			if err := applescript.AddNote(cmd.Context(), note.Title, note.Snippet, note.Folder); err != nil {
 				fmt.Printf("  Warning: failed to restore '%s': %v\n", note.Title, err)
 				continue
 			}
+			created, err := verifyCreated(cmd.Context(), database, note.Title, note.Folder, lastID)
+			if err != nil {
+				fmt.Printf("  Warning: %v\n", err)
+				continue
+			}
+			recordCreate(database, "restore", created, note.Title, note.Folder, lastID)
 			restored++
 		}
 
+		if dryRun {
+			fmt.Printf("\n[dry-run] Would restore %d/%d notes\n", restored, len(backup.Notes))
+			return nil
+		}
 		fmt.Printf("\nSuccessfully restored %d/%d notes\n", restored, len(backup.Notes))
 		return nil
 	},
@@ -1,53 +1,247 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"os"
 
-	"github.com/fishfisher/apple-notes/internal/applescript"
+	"github.com/fishfisher/apple-notes/internal/db"
 	"github.com/spf13/cobra"
 )
 
+var (
+	bulkSelection     noteSelection
+	bulkYes           bool
+	bulkMoveFrom      string
+	bulkMoveTo        string
+	bulkAppendContent string
+	bulkExportOutput  string
+	bulkExportFormat  string
+)
+
 var bulkCmd = &cobra.Command{
 	Use:   "bulk",
 	Short: "Bulk operations on notes",
-	Long:  `Perform bulk operations like moving all notes from one folder to another.`,
+	Long: `Perform operations on a selection of notes.
+
+Notes are selected with --query, --tag, --folder and --ids-from; when several
+are given, a note must match all of them. Without any of these, note IDs are
+read from stdin, so search results can be piped in:
+
+  apple-notes search foo --ids | apple-notes bulk tag '#foo'
+
+Each command previews the selected notes and asks for confirmation (skip it
+with --yes), then reports the result per note.`,
 }
 
 var bulkMoveCmd = &cobra.Command{
-	Use:   "move --from [source-folder] --to [target-folder]",
-	Short: "Move all notes from one folder to another",
+	Use:   "move --to [target-folder]",
+	Short: "Move selected notes to a folder",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// --from is kept for compatibility and means the same as --folder
+		if bulkMoveFrom != "" && bulkSelection.folder == "" {
+			bulkSelection.folder = bulkMoveFrom
+		}
+
+		return runBulk(cmd, "move", func(ctx context.Context, database *db.DB, note *db.Note) error {
+			return moveNoteTo(ctx, database, "bulk move", note, bulkMoveTo)
+		})
+	},
+}
+
+var bulkTagCmd = &cobra.Command{
+	Use:   "tag [tag]",
+	Short: "Add a tag to selected notes",
+	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		sourceFolder, _ := cmd.Flags().GetString("from")
-		targetFolder, _ := cmd.Flags().GetString("to")
+		tag := args[0]
+
+		return runBulk(cmd, "tag", func(ctx context.Context, database *db.DB, note *db.Note) error {
+			return tagNote(ctx, database, "bulk tag", note, tag)
+		})
+	},
+}
 
-		if sourceFolder == "" || targetFolder == "" {
-			return fmt.Errorf("both --from and --to flags are required")
+var bulkDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete selected notes",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runBulk(cmd, "delete", func(ctx context.Context, database *db.DB, note *db.Note) error {
+			return removeNote(ctx, database, "bulk delete", note)
+		})
+	},
+}
+
+var bulkAppendCmd = &cobra.Command{
+	Use:   "append --content [text]",
+	Short: "Append content to selected notes",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runBulk(cmd, "append to", func(ctx context.Context, database *db.DB, note *db.Note) error {
+			return appendToNote(ctx, database, "bulk append", note, bulkAppendContent)
+		})
+	},
+}
+
+var bulkExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export selected notes to a file",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if bulkExportOutput == "" {
+			bulkExportOutput = defaultExportPath(bulkExportFormat)
 		}
 
-		fmt.Printf("Move all notes from '%s' to '%s'? (y/N): ", sourceFolder, targetFolder)
-		var response string
-		fmt.Scanln(&response)
-		if response != "y" && response != "Y" {
-			fmt.Println("Bulk move cancelled")
+		var selected []db.Note
+		err := runBulk(cmd, "export", func(ctx context.Context, database *db.DB, note *db.Note) error {
+			selected = append(selected, *note)
 			return nil
+		})
+		if err != nil || len(selected) == 0 || dryRun {
+			return err
 		}
 
-		fmt.Printf("Moving notes from '%s' to '%s'...\n", sourceFolder, targetFolder)
-		count, err := applescript.BulkMoveNotes(sourceFolder, targetFolder)
-		if err != nil {
-			return fmt.Errorf("failed to move notes: %w", err)
+		if err := writeExport(selected, bulkExportOutput, bulkExportFormat); err != nil {
+			return err
 		}
-
-		fmt.Printf("Successfully moved %d notes\n", count)
+		statusf("Exported %d notes to %s\n", len(selected), bulkExportOutput)
 		return nil
 	},
 }
 
+// bulkResult is the outcome of a bulk operation for one note
+type bulkResult struct {
+	ID     string `json:"id"`
+	Title  string `json:"title"`
+	Folder string `json:"folder"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// runBulk resolves the selection, previews it, asks for confirmation and
+// applies fn to every selected note, reporting the result per note
+func runBulk(cmd *cobra.Command, verb string, fn func(ctx context.Context, database *db.DB, note *db.Note) error) error {
+	database, err := db.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer database.Close()
+
+	usesStdin := bulkSelection.empty() || bulkSelection.idsFrom == "-"
+	notes, err := bulkSelection.resolve(database)
+	if err != nil {
+		return err
+	}
+
+	if len(notes) == 0 {
+		statusf("No notes selected\n")
+		if structuredOutput() {
+			return printOutput([]bulkResult{})
+		}
+		return nil
+	}
+
+	statusf("Selected %d notes to %s:\n", len(notes), verb)
+	preview := &table{
+		columns: []tableColumn{{Header: "ID"}, {Header: "TITLE", Flexible: true}, {Header: "FOLDER"}},
+		indent:  "  ",
+	}
+	for _, note := range notes {
+		preview.addRow(note.ID, note.Title, note.Folder)
+	}
+	preview.render(previewOutput(), terminalWidth())
+
+	if !bulkYes {
+		ok, err := confirmBulk(fmt.Sprintf("%s %d notes? (y/N): ", capitalize(verb), len(notes)), usesStdin)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			statusf("Bulk operation cancelled\n")
+			return nil
+		}
+	}
+
+	var results []bulkResult
+	failed := 0
+	for i := range notes {
+		note := &notes[i]
+		result := bulkResult{ID: note.ID, Title: note.Title, Folder: note.Folder, Status: "ok"}
+		if err := fn(cmd.Context(), database, note); err != nil {
+			result.Status = "failed"
+			result.Error = err.Error()
+			failed++
+			statusf("  ✗ %s %s: %v\n", note.ID, note.Title, err)
+		} else {
+			statusf("  ✓ %s %s\n", note.ID, note.Title)
+		}
+		results = append(results, result)
+	}
+
+	if structuredOutput() {
+		if err := printOutput(results); err != nil {
+			return err
+		}
+	} else if dryRun {
+		fmt.Printf("[dry-run] Would %s %d notes\n", verb, len(notes)-failed)
+	} else {
+		fmt.Printf("\n%d succeeded, %d failed\n", len(notes)-failed, failed)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("bulk %s failed for %d of %d notes", verb, failed, len(notes))
+	}
+	return nil
+}
+
+// confirmBulk asks for confirmation. When stdin carried the note IDs, the
+// answer is read from the terminal instead.
+func confirmBulk(prompt string, usesStdin bool) (bool, error) {
+	if !usesStdin || dryRun {
+		return confirm(prompt), nil
+	}
+
+	tty, err := os.Open("/dev/tty")
+	if err != nil {
+		return false, fmt.Errorf("stdin is used for note IDs and no terminal is available; pass --yes to confirm")
+	}
+	defer tty.Close()
+	return confirmFrom(tty, prompt), nil
+}
+
+// previewOutput is where previews go; stderr when stdout is machine-readable
+func previewOutput() io.Writer {
+	if structuredOutput() {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// capitalize upper-cases the first letter of an ASCII verb
+func capitalize(s string) string {
+	if s == "" || s[0] < 'a' || s[0] > 'z' {
+		return s
+	}
+	return string(s[0]-'a'+'A') + s[1:]
+}
+
 func init() {
-	bulkMoveCmd.Flags().String("from", "", "Source folder")
-	bulkMoveCmd.Flags().String("to", "", "Target folder")
-	bulkMoveCmd.MarkFlagRequired("from")
+	for _, c := range []*cobra.Command{bulkMoveCmd, bulkTagCmd, bulkDeleteCmd, bulkAppendCmd, bulkExportCmd} {
+		bulkSelection.addFlags(c)
+		c.Flags().BoolVarP(&bulkYes, "yes", "y", false, "Skip confirmation prompt")
+		bulkCmd.AddCommand(c)
+	}
+
+	bulkMoveCmd.Flags().StringVar(&bulkMoveTo, "to", "", "Target folder")
+	bulkMoveCmd.Flags().StringVar(&bulkMoveFrom, "from", "", "Source folder (same as --folder)")
 	bulkMoveCmd.MarkFlagRequired("to")
 
-	bulkCmd.AddCommand(bulkMoveCmd)
+	bulkAppendCmd.Flags().StringVarP(&bulkAppendContent, "content", "c", "", "Content to append")
+	bulkAppendCmd.MarkFlagRequired("content")
+
+	bulkExportCmd.Flags().StringVar(&bulkExportOutput, "file", "", "Output file path (default: ~/Desktop/apple-notes-export.json)")
+	bulkExportCmd.Flags().StringVarP(&bulkExportFormat, "format", "t", "json", "Export format: json or txt")
 }
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/fishfisher/apple-notes/internal/applescript"
+	"github.com/fishfisher/apple-notes/internal/db"
+	"github.com/fishfisher/apple-notes/internal/markup"
+	"github.com/spf13/cobra"
+)
+
+var (
+	captureFile   string
+	captureTitle  string
+	captureFolder string
+	captureTags   []string
+	captureFormat string
+)
+
+// captureTitleLength is the maximum length of a derived title
+const captureTitleLength = 80
+
+var captureCmd = &cobra.Command{
+	Use:   "capture",
+	Short: "Create a note from piped text",
+	Long: `Create a note from everything read on stdin (or from --clipboard-file) and
+print its ID.
+
+The title is taken from the first "# heading", or else the first non-empty
+line. Markdown input is converted to Notes formatting; plain text is kept line
+by line. The note goes to the inbox folder: --folder, or the capture.folder config
+value (see 'apple-notes config'), or "Notes".
+
+Examples:
+  make test 2>&1 | apple-notes capture --tag ci
+  pbpaste > /tmp/clip.txt && apple-notes capture --clipboard-file /tmp/clip.txt`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		text, err := readCapture()
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(text) == "" {
+			return fmt.Errorf("nothing to capture: input is empty")
+		}
+
+		title := captureTitle
+		if title == "" {
+			title = markup.Title(text, captureTitleLength)
+		}
+
+		isMarkdown := markup.IsMarkdown(text)
+		switch captureFormat {
+		case "markdown":
+			isMarkdown = true
+		case "text":
+			isMarkdown = false
+		case "auto":
+		default:
+			return fmt.Errorf("unsupported format: %s (use auto, markdown or text)", captureFormat)
+		}
+
+		var body string
+		if isMarkdown {
+			body = markup.MarkdownToHTML(text)
+		} else {
+			body = markup.TextToHTML(text)
+		}
+		if tags := captureTagLine(); tags != "" {
+			body += "<div><br></div>" + markup.TextToHTML(tags)
+		}
+
+		database, err := db.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		defer database.Close()
+
+		lastID, err := database.MaxObjectID()
+		if err != nil {
+			return err
+		}
+
+		kind := "text"
+		if isMarkdown {
+			kind = "markdown"
+		}
+		fmt.Fprintf(os.Stderr, "Capturing %s as '%s' in folder '%s'...\n", kind, title, captureFolder)
+		if err := applescript.AddNoteHTML(cmd.Context(), title, body, captureFolder); err != nil {
+			return fmt.Errorf("failed to create note: %w", err)
+		}
+
+		note, err := verifyCreated(cmd.Context(), database, title, captureFolder, lastID)
+		if err != nil {
+			return err
+		}
+		note = recordCreate(database, "capture", note, title, captureFolder, lastID)
+
+		if structuredOutput() || dryRun {
+			return reportWrite(noteResult("created", note), "Note captured")
+		}
+		if note == nil {
+			fmt.Fprintln(os.Stderr, "Note captured (ID unknown: the new note has not shown up yet)")
+			return nil
+		}
+		// Only the ID goes to stdout, so it can be used by the next command
+		fmt.Println(note.ID)
+		return nil
+	},
+}
+
+// readCapture reads the text to capture from --clipboard-file or stdin
+func readCapture() (string, error) {
+	if captureFile != "" {
+		data, err := os.ReadFile(captureFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read file: %w", err)
+		}
+		return string(data), nil
+	}
+
+	if stdinIsTerminal() {
+		return "", fmt.Errorf("nothing to capture: pipe text on stdin or use --clipboard-file")
+	}
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", fmt.Errorf("failed to read input: %w", err)
+	}
+	return string(data), nil
+}
+
+// captureTagLine returns the hashtags to add to a captured note
+func captureTagLine() string {
+	var tags []string
+	for _, tag := range captureTags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		if !strings.HasPrefix(tag, "#") {
+			tag = "#" + tag
+		}
+		tags = append(tags, tag)
+	}
+	if len(tags) == 0 {
+		return ""
+	}
+	return strings.Join(tags, " ")
+}
+
+func init() {
+	captureCmd.Flags().StringVar(&captureFile, "clipboard-file", "", "Read the text from a file instead of stdin")
+	captureCmd.Flags().StringVarP(&captureTitle, "title", "t", "", "Note title (default: derived from the text)")
+	captureCmd.Flags().StringVarP(&captureFolder, "folder", "f", "Notes", "Inbox folder")
+	captureCmd.Flags().StringArrayVar(&captureTags, "tag", nil, "Hashtag to add (repeatable)")
+	captureCmd.Flags().StringVar(&captureFormat, "format", "auto", "Input format: auto, markdown or text")
+}
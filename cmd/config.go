@@ -0,0 +1,302 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/fishfisher/apple-notes/internal/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// cfg is the loaded config file, set in Execute
+var cfg = &config.Config{}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage flag defaults and aliases",
+	Long: `Manage the config file at ~/.config/apple-notes/config.json (or
+$XDG_CONFIG_HOME/apple-notes/config.json).
+
+Flag defaults are keyed by command path and flag name, joined with dots:
+
+  recent.limit          --limit of 'recent'
+  template.use.folder   --folder of 'template use'
+  output                the global --output flag
+
+Each flag takes its value from, in order of precedence:
+  1. the command line
+  2. the environment: APPLE_NOTES_<COMMAND>_<FLAG>, e.g. APPLE_NOTES_RECENT_LIMIT
+     or APPLE_NOTES_TEMPLATE_USE_FOLDER (APPLE_NOTES_OUTPUT for global flags).
+     Empty variables are ignored. APPLE_NOTES_JOURNAL_FOLDER,
+     APPLE_NOTES_DAILY_TITLE and APPLE_NOTES_INBOX_FOLDER are still read for
+     daily --folder, daily --title-format and capture --folder.
+  3. the config file
+  4. the built-in default
+
+Aliases are keyed "alias.<name>" and expand to a command line when used as
+the first argument:
+
+  apple-notes config set alias.standup 'template use Standup --title "Standup {{date}}"'
+  apple-notes standup --folder Work`,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get [key]",
+	Short: "Print a config value",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		value, ok := cfg.Get(args[0])
+		if !ok {
+			return fmt.Errorf("%s is not set", args[0])
+		}
+		fmt.Println(value)
+		return nil
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set [key] [value]",
+	Short: "Set a config value",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, value := args[0], args[1]
+		if err := validateConfigKey(key, value); err != nil {
+			return err
+		}
+
+		cfg.Set(key, value)
+		if err := cfg.Save(); err != nil {
+			return err
+		}
+		fmt.Printf("Set %s = %s\n", key, value)
+		return nil
+	},
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset [key]",
+	Short: "Remove a config value",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !cfg.Unset(args[0]) {
+			return fmt.Errorf("%s is not set", args[0])
+		}
+		if err := cfg.Save(); err != nil {
+			return err
+		}
+		fmt.Printf("Removed %s\n", args[0])
+		return nil
+	},
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List config values",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if structuredOutput() {
+			return printOutput(cfg)
+		}
+
+		keys := cfg.Keys()
+		if len(keys) == 0 {
+			path, _ := config.Path()
+			fmt.Printf("No config values set (%s)\n", path)
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "KEY\tVALUE")
+		for _, key := range keys {
+			value, _ := cfg.Get(key)
+			fmt.Fprintf(w, "%s\t%s\n", key, value)
+		}
+		w.Flush()
+		return nil
+	},
+}
+
+// validateConfigKey checks that a key names an existing flag (and that the
+// value is valid for it) or a new alias
+func validateConfigKey(key, value string) error {
+	if name, ok := config.IsAlias(key); ok {
+		if name == "" || strings.ContainsAny(name, " \t") {
+			return fmt.Errorf("invalid alias name %q", name)
+		}
+		if c, _, err := rootCmd.Find([]string{name}); err == nil && c != rootCmd {
+			return fmt.Errorf("alias %s would shadow the %s command", name, c.Name())
+		}
+		if _, err := config.SplitArgs(value); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	parts := strings.Split(key, ".")
+	flagName := parts[len(parts)-1]
+	target := rootCmd
+	if len(parts) > 1 {
+		c, rest, err := rootCmd.Find(parts[:len(parts)-1])
+		if err != nil || len(rest) > 0 || c == rootCmd {
+			return fmt.Errorf("unknown command in key %s", key)
+		}
+		target = c
+	}
+
+	var flag *pflag.Flag
+	if target == rootCmd {
+		flag = rootCmd.PersistentFlags().Lookup(flagName)
+	} else {
+		flag = target.Flags().Lookup(flagName)
+		if flag == nil {
+			flag = target.InheritedFlags().Lookup(flagName)
+		}
+	}
+	if flag == nil || flagName == "help" {
+		return fmt.Errorf("unknown flag %s for %s", flagName, target.CommandPath())
+	}
+
+	// Check the value parses, without touching the real flag
+	if err := newProbeValue(flag.Value).Set(value); err != nil {
+		return fmt.Errorf("invalid value for %s: %w", key, err)
+	}
+	return nil
+}
+
+// newProbeValue returns a fresh flag value of the same type as v, for
+// validating config values
+func newProbeValue(v pflag.Value) pflag.Value {
+	fs := pflag.NewFlagSet("probe", pflag.ContinueOnError)
+	switch v.Type() {
+	case "bool":
+		fs.Bool("v", false, "")
+	case "int":
+		fs.Int("v", 0, "")
+	case "duration":
+		fs.Duration("v", 0, "")
+	case "float64":
+		fs.Float64("v", 0, "")
+	default:
+		fs.String("v", "", "")
+	}
+	return fs.Lookup("v").Value
+}
+
+// applyFlagDefaults fills the flags of cmd that were not given on the command
+// line from the environment and then the config file
+func applyFlagDefaults(cmd *cobra.Command) error {
+	var err error
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		if err != nil || flag.Changed || flag.Name == "help" || flag.Name == "version" {
+			return
+		}
+		for _, path := range flagDefaultPaths(cmd, flag.Name) {
+			value, source, ok := lookupEnv(envKey(path, flag.Name))
+			if !ok {
+				value, ok = cfg.Defaults[configKey(path, flag.Name)]
+				source = "config key " + configKey(path, flag.Name)
+			}
+			if !ok {
+				continue
+			}
+			if setErr := cmd.Flags().Set(flag.Name, value); setErr != nil {
+				err = fmt.Errorf("invalid value %q for --%s from %s: %w", value, flag.Name, source, setErr)
+			}
+			return
+		}
+	})
+	return err
+}
+
+// legacyEnv maps flag environment variables to the names daily and capture
+// read before the config file existed, still honoured as fallbacks
+var legacyEnv = map[string]string{
+	"APPLE_NOTES_DAILY_FOLDER":       "APPLE_NOTES_JOURNAL_FOLDER",
+	"APPLE_NOTES_DAILY_TITLE_FORMAT": "APPLE_NOTES_DAILY_TITLE",
+	"APPLE_NOTES_CAPTURE_FOLDER":     "APPLE_NOTES_INBOX_FOLDER",
+}
+
+// lookupEnv returns the value of an environment variable, or of its legacy
+// name, and the name it came from. Empty values count as unset.
+func lookupEnv(key string) (string, string, bool) {
+	for _, name := range []string{key, legacyEnv[key]} {
+		if value := os.Getenv(name); name != "" && value != "" {
+			return value, name, true
+		}
+	}
+	return "", "", false
+}
+
+// flagDefaultPaths returns the command paths whose defaults apply to a flag,
+// most specific first: the command itself, then each ancestor that defines
+// the flag as persistent. Root flags use the empty path.
+func flagDefaultPaths(cmd *cobra.Command, name string) []string {
+	// A local flag shadows persistent flags of the same name, such as
+	// export's old --output file flag and the global --output
+	if cmd != cmd.Root() && cmd.LocalNonPersistentFlags().Lookup(name) != nil {
+		return []string{strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")}
+	}
+	root := cmd.Root()
+	var paths []string
+	for c := cmd; c != nil; c = c.Parent() {
+		if c != cmd && c.PersistentFlags().Lookup(name) == nil {
+			continue
+		}
+		if c == root {
+			if c.PersistentFlags().Lookup(name) != nil {
+				paths = append(paths, "")
+			}
+			continue
+		}
+		paths = append(paths, strings.TrimPrefix(c.CommandPath(), root.Name()+" "))
+	}
+	return paths
+}
+
+// configKey returns the config key for a flag of a command path
+func configKey(path, flag string) string {
+	if path == "" {
+		return flag
+	}
+	return strings.ReplaceAll(path, " ", ".") + "." + flag
+}
+
+// envKey returns the environment variable for a flag of a command path
+func envKey(path, flag string) string {
+	name := "APPLE_NOTES_" + flag
+	if path != "" {
+		name = "APPLE_NOTES_" + path + "_" + flag
+	}
+	return strings.ToUpper(strings.NewReplacer(" ", "_", "-", "_", ".", "_").Replace(name))
+}
+
+// expandAlias replaces a leading alias in args with its definition
+func expandAlias(args []string) ([]string, error) {
+	if len(args) == 0 {
+		return args, nil
+	}
+	definition, ok := cfg.Aliases[args[0]]
+	if !ok {
+		return args, nil
+	}
+	if c, _, err := rootCmd.Find(args[:1]); err == nil && c != rootCmd {
+		// Built-in commands always win
+		return args, nil
+	}
+
+	expanded, err := config.SplitArgs(definition)
+	if err != nil {
+		return nil, fmt.Errorf("invalid alias %s: %w", args[0], err)
+	}
+	return append(expanded, args[1:]...), nil
+}
+
+func init() {
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configUnsetCmd)
+	configCmd.AddCommand(configListCmd)
+}
@@ -0,0 +1,275 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fishfisher/apple-notes/internal/applescript"
+	"github.com/fishfisher/apple-notes/internal/db"
+	"github.com/fishfisher/apple-notes/internal/templates"
+	"github.com/spf13/cobra"
+)
+
+var (
+	dailyDate        string
+	dailyAppend      string
+	dailyFolder      string
+	dailyTitleFormat string
+	dailyTemplate    string
+	dailyMonth       string
+)
+
+var dailyCmd = &cobra.Command{
+	Use:   "daily",
+	Short: "Open or create today's journal note",
+	Long: `Create the daily note for a date in the journal folder, or show it if it
+already exists. With --append, a timestamped entry is added to it.
+
+The title is rendered from --title-format, which can use the template
+functions {{date}}, {{date "layout"}} and {{weekday}} for the note's date.
+With --template, a new daily note starts from that template, rendered for the
+note's date.
+
+Defaults can be set with 'apple-notes config set' (daily.folder,
+daily.title-format, daily.template) or in the environment
+(APPLE_NOTES_DAILY_FOLDER, APPLE_NOTES_DAILY_TITLE_FORMAT,
+APPLE_NOTES_DAILY_TEMPLATE).`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		date, err := parseDailyDate(dailyDate)
+		if err != nil {
+			return err
+		}
+		title, err := dailyTitle(date)
+		if err != nil {
+			return err
+		}
+
+		database, err := db.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		defer database.Close()
+
+		note, err := findDailyNote(database, title)
+		if err != nil {
+			return err
+		}
+
+		entry := ""
+		if dailyAppend != "" {
+			entry = time.Now().Format("15:04") + " " + dailyAppend
+		}
+
+		if note != nil {
+			if entry == "" {
+				return reportWrite(noteResult("exists", note), fmt.Sprintf("Daily note '%s' already exists", note.Title))
+			}
+			statusf("Appending to daily note '%s'...\n", note.Title)
+			if err := appendToNote(cmd.Context(), database, "daily", note, entry); err != nil {
+				return fmt.Errorf("failed to append to daily note: %w", err)
+			}
+			note, err = database.GetNote(note.ID)
+			if err != nil {
+				return err
+			}
+			return reportWrite(noteResult("appended", note), "Entry added to daily note")
+		}
+
+		body := ""
+		if dailyTemplate != "" {
+			template, err := templates.Get(dailyTemplate)
+			if err != nil {
+				return err
+			}
+			_, body, err = renderTemplate(*template, title, map[string]string{}, date)
+			if err != nil {
+				return err
+			}
+		}
+		if entry != "" {
+			body = strings.TrimRight(body, "\n")
+			if body != "" {
+				body += "\n"
+			}
+			body += entry
+		}
+
+		lastID, err := database.MaxObjectID()
+		if err != nil {
+			return err
+		}
+
+		// The default folder does not exist until the first daily note
+		folderID, err := database.FindFolder(dailyFolder)
+		if err != nil {
+			return err
+		}
+		if folderID == 0 {
+			statusf("Creating folder '%s'...\n", dailyFolder)
+			if err := applescript.CreateFolder(cmd.Context(), dailyFolder); err != nil {
+				return fmt.Errorf("failed to create folder: %w", err)
+			}
+			if err := verifyFolder(cmd.Context(), database, dailyFolder, true); err != nil {
+				return err
+			}
+		}
+
+		statusf("Creating daily note '%s' in folder '%s'...\n", title, dailyFolder)
+		if err := applescript.AddNote(cmd.Context(), title, body, dailyFolder); err != nil {
+			return fmt.Errorf("failed to create daily note: %w", err)
+		}
+
+		note, err = verifyCreated(cmd.Context(), database, title, dailyFolder, lastID)
+		if err != nil {
+			return err
+		}
+		note = recordCreate(database, "daily", note, title, dailyFolder, lastID)
+
+		return reportWrite(noteResult("created", note), "Daily note created successfully")
+	},
+}
+
+var dailyListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Show which days of a month have a daily note",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		month := time.Now()
+		if dailyMonth != "" {
+			var err error
+			month, err = time.ParseInLocation("2006-01", dailyMonth, time.Local)
+			if err != nil {
+				return fmt.Errorf("invalid --month (use YYYY-MM): %w", err)
+			}
+		}
+		first := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.Local)
+
+		database, err := db.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		defer database.Close()
+
+		notes, err := database.ListNotes(folderName(dailyFolder))
+		if err != nil {
+			return fmt.Errorf("failed to list notes: %w", err)
+		}
+		byTitle := make(map[string]db.Note)
+		for _, note := range notes {
+			if _, ok := byTitle[note.Title]; !ok {
+				byTitle[note.Title] = note
+			}
+		}
+
+		type dailyEntry struct {
+			Date  string `json:"date"`
+			ID    string `json:"id"`
+			Title string `json:"title"`
+		}
+		entries := []dailyEntry{}
+		covered := make(map[int]bool)
+		days := first.AddDate(0, 1, -1).Day()
+		for day := 1; day <= days; day++ {
+			date := first.AddDate(0, 0, day-1)
+			title, err := dailyTitle(date)
+			if err != nil {
+				return err
+			}
+			if note, ok := byTitle[title]; ok {
+				covered[day] = true
+				entries = append(entries, dailyEntry{Date: date.Format("2006-01-02"), ID: note.ID, Title: note.Title})
+			}
+		}
+
+		if structuredOutput() {
+			return printOutput(entries)
+		}
+
+		printDailyCalendar(first, covered)
+		fmt.Printf("\n%d of %d days have a daily note in '%s'\n", len(entries), days, dailyFolder)
+		for _, entry := range entries {
+			fmt.Printf("  %s  %s  %s\n", entry.Date, entry.ID, entry.Title)
+		}
+		return nil
+	},
+}
+
+// printDailyCalendar prints a month calendar, weeks starting on Monday, with
+// days that have a note marked by *
+func printDailyCalendar(first time.Time, covered map[int]bool) {
+	fmt.Printf("%s\n", first.Format("January 2006"))
+	fmt.Println(" Mo  Tu  We  Th  Fr  Sa  Su")
+
+	offset := (int(first.Weekday()) + 6) % 7
+
+	line := strings.Repeat("    ", offset)
+	days := first.AddDate(0, 1, -1).Day()
+	for day := 1; day <= days; day++ {
+		mark := " "
+		if covered[day] {
+			mark = "*"
+		}
+		line += fmt.Sprintf("%3d%s", day, mark)
+		if (offset+day)%7 == 0 || day == days {
+			fmt.Println(strings.TrimRight(line, " "))
+			line = ""
+		}
+	}
+}
+
+// parseDailyDate parses --date: YYYY-MM-DD, "today" or "yesterday"
+func parseDailyDate(value string) (time.Time, error) {
+	now := time.Now()
+	switch strings.ToLower(value) {
+	case "", "today":
+		return now, nil
+	case "yesterday":
+		return now.AddDate(0, 0, -1), nil
+	}
+
+	date, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --date (use YYYY-MM-DD, today or yesterday): %w", err)
+	}
+	return date, nil
+}
+
+// dailyTitle renders the daily note title for a date
+func dailyTitle(date time.Time) (string, error) {
+	title, err := templates.Render("title-format", dailyTitleFormat, nil, date)
+	if err != nil {
+		return "", err
+	}
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return "", fmt.Errorf("--title-format renders an empty title")
+	}
+	return title, nil
+}
+
+// findDailyNote returns the daily note with the given title, or nil
+func findDailyNote(database *db.DB, title string) (*db.Note, error) {
+	notes, err := database.ListNotes(folderName(dailyFolder))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notes: %w", err)
+	}
+	for i := range notes {
+		if notes[i].Title == title {
+			return &notes[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func init() {
+	dailyCmd.PersistentFlags().StringVarP(&dailyFolder, "folder", "f", "Journal", "Journal folder")
+	dailyCmd.PersistentFlags().StringVar(&dailyTitleFormat, "title-format", "{{date}}", "Title format for daily notes")
+	dailyCmd.Flags().StringVarP(&dailyDate, "date", "d", "", "Date of the daily note (YYYY-MM-DD, today or yesterday)")
+	dailyCmd.Flags().StringVarP(&dailyAppend, "append", "a", "", "Add a timestamped entry to the daily note")
+	dailyCmd.Flags().StringVarP(&dailyTemplate, "template", "t", "", "Template for new daily notes")
+
+	dailyListCmd.Flags().StringVar(&dailyMonth, "month", "", "Month to show (YYYY-MM, default: this month)")
+	dailyCmd.AddCommand(dailyListCmd)
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/fishfisher/apple-notes/internal/applescript"
 	"github.com/fishfisher/apple-notes/internal/db"
+	"github.com/fishfisher/apple-notes/internal/journal"
 	"github.com/spf13/cobra"
 )
 
@@ -25,29 +26,39 @@ var deleteCmd = &cobra.Command{
 		}
 		defer database.Close()
 
-		note, err := database.GetNote(noteID)
+		note, err := resolveNoteForWrite(database, noteID)
 		if err != nil {
-			return fmt.Errorf("note not found: %w", err)
+			return err
 		}
 
 		// Confirm deletion unless --force is used
-		if !deleteForce {
-			fmt.Printf("Delete note '%s' from folder '%s'? (y/N): ", note.Title, note.Folder)
-			var response string
-			fmt.Scanln(&response)
-			if response != "y" && response != "Y" {
-				fmt.Println("Deletion cancelled")
-				return nil
-			}
+		if !deleteForce && !confirm(fmt.Sprintf("Delete note '%s' from folder '%s'? (y/N): ", note.Title, note.Folder)) {
+			statusf("Deletion cancelled\n")
+			return nil
 		}
 
-		fmt.Printf("Deleting note '%s'...\n", note.Title)
-		if err := applescript.DeleteNote(note.Title); err != nil {
+		scriptID, err := database.AppleScriptID(note.ID)
+		if err != nil {
+			return err
+		}
+
+		entry, err := snapshotNote(cmd.Context(), database, "delete", journal.ActionDelete, note)
+		if err != nil {
+			return err
+		}
+
+		planWrite("delete", *note)
+		statusf("Deleting note '%s'...\n", note.Title)
+		if err := applescript.DeleteNoteByID(cmd.Context(), scriptID); err != nil {
 			return fmt.Errorf("failed to delete note: %w", err)
 		}
+		recordJournal(entry)
+
+		if err := verifyDeleted(cmd.Context(), database, note.ID); err != nil {
+			return err
+		}
 
-		fmt.Println("Note deleted successfully")
-		return nil
+		return reportWrite(noteResult("deleted", note), "Note deleted successfully")
 	},
 }
 
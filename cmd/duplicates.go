@@ -4,25 +4,52 @@ import (
 	"fmt"
 
 	"github.com/fishfisher/apple-notes/internal/db"
+	"github.com/fishfisher/apple-notes/internal/dedupe"
+	"github.com/fishfisher/apple-notes/internal/textnorm"
 	"github.com/spf13/cobra"
 )
 
+var (
+	duplicatesSimilar   bool
+	duplicatesThreshold float64
+	duplicatesDiff      bool
+)
+
 var duplicatesCmd = &cobra.Command{
 	Use:   "duplicates",
 	Short: "Find duplicate notes",
-	Long:  `Find notes with identical titles.`,
+	Long: `Find notes with identical titles, ignoring case and accents unless
+--strict is given.
+
+With --similar, notes are compared by content instead: bodies are split into
+word shingles and candidates are found with MinHash, so near-copies with
+different titles (e.g. "Meeting" and "Meeting (1)") are grouped together.
+--threshold sets the minimum Jaccard similarity (0-1).`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		database, err := db.Open()
 		if err != nil {
 			return fmt.Errorf("failed to open database: %w", err)
 		}
 		defer database.Close()
+		database.SetStrict(strictMatching)
+
+		if duplicatesSimilar {
+			return printSimilarNotes(database)
+		}
 
 		duplicates, err := database.FindDuplicates()
 		if err != nil {
 			return fmt.Errorf("failed to find duplicates: %w", err)
 		}
 
+		if structuredOutput() {
+			records := []duplicateGroupRecord{}
+			for _, group := range duplicates {
+				records = append(records, duplicateGroupRecord{Title: group[0].Title, Notes: noteRecords(group)})
+			}
+			return printOutput(records)
+		}
+
 		if len(duplicates) == 0 {
 			fmt.Println("No duplicate notes found")
 			return nil
@@ -46,3 +73,85 @@ var duplicatesCmd = &cobra.Command{
 		return nil
 	},
 }
+
+// printSimilarNotes prints groups of notes with similar content
+func printSimilarNotes(database *db.DB) error {
+	if duplicatesThreshold <= 0 || duplicatesThreshold > 1 {
+		return fmt.Errorf("--threshold must be between 0 and 1")
+	}
+
+	notes, err := database.ListNoteBodies("")
+	if err != nil {
+		return fmt.Errorf("failed to load note bodies: %w", err)
+	}
+
+	byID := make(map[string]db.Note, len(notes))
+	docs := make([]dedupe.Document, 0, len(notes))
+	for _, note := range notes {
+		byID[note.ID] = note
+		text := note.Body
+		if !strictMatching {
+			text = textnorm.Fold(text)
+		}
+		docs = append(docs, dedupe.Document{ID: note.ID, Text: text})
+	}
+
+	groups := dedupe.FindSimilar(docs, duplicatesThreshold)
+
+	if structuredOutput() {
+		records := []duplicateGroupRecord{}
+		for _, group := range groups {
+			var notes []db.Note
+			for _, id := range group.IDs {
+				note := byID[id]
+				note.Body = ""
+				notes = append(notes, note)
+			}
+			records = append(records, duplicateGroupRecord{Notes: noteRecords(notes), Pairs: similarityRecords(group.Pairs)})
+		}
+		return printOutput(records)
+	}
+
+	if len(groups) == 0 {
+		fmt.Printf("No similar notes found (threshold %.0f%%)\n", duplicatesThreshold*100)
+		return nil
+	}
+
+	fmt.Printf("Found %d sets of similar notes:\n\n", len(groups))
+
+	for i, group := range groups {
+		fmt.Printf("%d. %d similar notes\n", i+1, len(group.IDs))
+		for j, id := range group.IDs {
+			note := byID[id]
+			fmt.Printf("   %c. ID: %s, Title: %s, Folder: %s, Modified: %s\n",
+				'a'+j,
+				note.ID,
+				note.Title,
+				note.Folder,
+				note.Modified.Format("2006-01-02 15:04"),
+			)
+		}
+		for _, pair := range group.Pairs {
+			fmt.Printf("   %s ~ %s: %.0f%% similar\n", pair.A, pair.B, pair.Similarity*100)
+		}
+
+		if duplicatesDiff {
+			for _, pair := range group.Pairs {
+				fmt.Printf("\n   --- %s (%s)\n   +++ %s (%s)\n", pair.A, byID[pair.A].Title, pair.B, byID[pair.B].Title)
+				for _, line := range dedupe.Diff(byID[pair.A].Body, byID[pair.B].Body) {
+					fmt.Printf("   %c %s\n", line.Op, line.Text)
+				}
+			}
+		}
+		fmt.Println()
+	}
+
+	return nil
+}
+
+func init() {
+	duplicatesCmd.Flags().BoolVar(&duplicatesSimilar, "similar", false, "Find notes with similar content instead of identical titles")
+	duplicatesCmd.Flags().Float64Var(&duplicatesThreshold, "threshold", 0.8, "Minimum similarity for --similar (0-1)")
+	addStrictFlag(duplicatesCmd)
+	duplicatesCmd.Flags().BoolVar(&duplicatesDiff, "diff", false, "Show a diff between similar notes")
+}
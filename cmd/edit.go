@@ -1,13 +1,11 @@
 package cmd
 
 import (
-	"bufio"
 	"fmt"
-	"os"
-	"strings"
 
 	"github.com/fishfisher/apple-notes/internal/applescript"
 	"github.com/fishfisher/apple-notes/internal/db"
+	"github.com/fishfisher/apple-notes/internal/journal"
 	"github.com/spf13/cobra"
 )
 
@@ -41,13 +39,13 @@ Use --force to skip the confirmation prompt.`,
 		}
 		defer database.Close()
 
-		note, err := database.GetNote(noteID)
+		note, err := resolveNoteForWrite(database, noteID)
 		if err != nil {
-			return fmt.Errorf("note not found: %w", err)
+			return err
 		}
 
 		// Check for rich content (images, attachments, etc.)
-		hasRichContent, err := database.HasRichContent(note.Title)
+		hasRichContent, err := database.HasRichContent(note.ID)
 		if err != nil {
 			return fmt.Errorf("failed to check note content: %w", err)
 		}
@@ -70,39 +68,53 @@ Use --force to skip the confirmation prompt.`,
 		// If body not provided via flag, read from stdin or prompt
 		newBody := editBody
 		if newBody == "" {
-			fmt.Printf("Editing note '%s'\n", note.Title)
-			fmt.Println("Enter new body (Ctrl+D when done):")
-			scanner := bufio.NewScanner(os.Stdin)
-			var lines []string
-			for scanner.Scan() {
-				lines = append(lines, scanner.Text())
+			if stdinIsTerminal() {
+				fmt.Printf("Editing note '%s'\n", note.Title)
 			}
-			if err := scanner.Err(); err != nil {
-				return fmt.Errorf("failed to read input: %w", err)
+			newBody, err = readInput("Enter new body (Ctrl+D when done):")
+			if err != nil {
+				return err
 			}
-			newBody = strings.Join(lines, "\n")
 		}
 
 		// Warn about attachments unless --force is used
 		if !editForce {
 			fmt.Println("\nWARNING: This will replace the note body with plain text.")
 			fmt.Println("Any images, attachments, tables, or formatting will be lost.")
-			fmt.Print("Continue? (y/N): ")
-			var response string
-			fmt.Scanln(&response)
-			if response != "y" && response != "Y" {
-				fmt.Println("Edit cancelled")
+			if !confirm("Continue? (y/N): ") {
+				statusf("Edit cancelled\n")
 				return nil
 			}
 		}
 
-		fmt.Printf("Updating note '%s'...\n", note.Title)
-		if err := applescript.EditNote(note.Title, newTitle, newBody); err != nil {
+		before, err := database.ModificationStamp(note.ID)
+		if err != nil {
+			return err
+		}
+
+		scriptID, err := database.AppleScriptID(note.ID)
+		if err != nil {
+			return err
+		}
+
+		entry, err := snapshotNote(cmd.Context(), database, "edit", journal.ActionUpdate, note)
+		if err != nil {
+			return err
+		}
+
+		planWrite("edit", *note)
+		statusf("Updating note '%s'...\n", note.Title)
+		if err := applescript.EditNoteByID(cmd.Context(), scriptID, newTitle, newBody); err != nil {
 			return fmt.Errorf("failed to edit note: %w", err)
 		}
+		recordJournal(entry)
+
+		note, err = verifyModified(cmd.Context(), database, note.ID, before)
+		if err != nil {
+			return err
+		}
 
-		fmt.Println("Note updated successfully")
-		return nil
+		return reportWrite(noteResult("updated", note), "Note updated successfully")
 	},
 }
 
@@ -5,21 +5,26 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/fishfisher/apple-notes/internal/db"
+	"github.com/fishfisher/apple-notes/internal/query"
 	"github.com/spf13/cobra"
 )
 
 var (
-	exportOutput string
+	exportFile   string
 	exportFormat string
 	exportFolder string
+	exportQuery  string
 )
 
 var exportCmd = &cobra.Command{
 	Use:   "export",
 	Short: "Export notes to a file",
-	Long:  `Export notes to JSON or text format.`,
+	Long: `Export notes to JSON or text format.
+
+Use --query to export only notes matching a search query (see search --help).`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		database, err := db.Open()
 		if err != nil {
@@ -27,9 +32,24 @@ var exportCmd = &cobra.Command{
 		}
 		defer database.Close()
 
-		notes, err := database.ListNotes(exportFolder)
-		if err != nil {
-			return fmt.Errorf("failed to list notes: %w", err)
+		var notes []db.Note
+		if exportQuery != "" {
+			text := exportQuery
+			if exportFolder != "" {
+				text = fmt.Sprintf("folder:%q (%s)", exportFolder, exportQuery)
+			}
+			q, err := query.Parse(text, time.Now())
+			if err != nil {
+				return err
+			}
+			if notes, err = q.Find(database); err != nil {
+				return err
+			}
+		} else {
+			notes, err = database.ListNotes(exportFolder)
+			if err != nil {
+				return fmt.Errorf("failed to list notes: %w", err)
+			}
 		}
 
 		if len(notes) == 0 {
@@ -38,46 +58,64 @@ var exportCmd = &cobra.Command{
 		}
 
 		// Default output path
-		if exportOutput == "" {
-			home, _ := os.UserHomeDir()
-			exportOutput = filepath.Join(home, "Desktop", "apple-notes-export.json")
-			if exportFormat == "txt" {
-				exportOutput = filepath.Join(home, "Desktop", "apple-notes-export.txt")
-			}
-		}
-
-		var data []byte
-		switch exportFormat {
-		case "json":
-			data, err = json.MarshalIndent(notes, "", "  ")
-			if err != nil {
-				return fmt.Errorf("failed to marshal JSON: %w", err)
-			}
-		case "txt":
-			var text string
-			for _, note := range notes {
-				text += fmt.Sprintf("Title: %s\n", note.Title)
-				text += fmt.Sprintf("Folder: %s\n", note.Folder)
-				text += fmt.Sprintf("Modified: %s\n", note.Modified.Format("2006-01-02 15:04:05"))
-				text += fmt.Sprintf("\n%s\n", note.Snippet)
-				text += "\n" + string(make([]byte, 80)) + "\n\n"
-			}
-			data = []byte(text)
-		default:
-			return fmt.Errorf("unsupported format: %s (use json or txt)", exportFormat)
+		if exportFile == "" {
+			exportFile = defaultExportPath(exportFormat)
 		}
 
-		if err := os.WriteFile(exportOutput, data, 0644); err != nil {
-			return fmt.Errorf("failed to write file: %w", err)
+		if err := writeExport(notes, exportFile, exportFormat); err != nil {
+			return err
 		}
 
-		fmt.Printf("Exported %d notes to %s\n", len(notes), exportOutput)
+		fmt.Printf("Exported %d notes to %s\n", len(notes), exportFile)
 		return nil
 	},
 }
 
+// defaultExportPath returns the export file used when no output path is given
+func defaultExportPath(format string) string {
+	home, _ := os.UserHomeDir()
+	if format == "txt" {
+		return filepath.Join(home, "Desktop", "apple-notes-export.txt")
+	}
+	return filepath.Join(home, "Desktop", "apple-notes-export.json")
+}
+
+// writeExport writes notes to path in the given format (json or txt)
+func writeExport(notes []db.Note, path, format string) error {
+	var data []byte
+	switch format {
+	case "json":
+		var err error
+		data, err = json.MarshalIndent(notes, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+	case "txt":
+		var text string
+		for _, note := range notes {
+			text += fmt.Sprintf("Title: %s\n", note.Title)
+			text += fmt.Sprintf("Folder: %s\n", note.Folder)
+			text += fmt.Sprintf("Modified: %s\n", note.Modified.Format("2006-01-02 15:04:05"))
+			text += fmt.Sprintf("\n%s\n", note.Snippet)
+			text += "\n" + string(make([]byte, 80)) + "\n\n"
+		}
+		data = []byte(text)
+	default:
+		return fmt.Errorf("unsupported format: %s (use json or txt)", format)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
+
 func init() {
-	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "Output file path (default: ~/Desktop/apple-notes-export.json)")
+	exportCmd.Flags().StringVar(&exportFile, "file", "", "Output file path (default: ~/Desktop/apple-notes-export.json)")
+	// --output/-o predates the global --output format flag it shadows
+	exportCmd.Flags().StringVarP(&exportFile, "output", "o", "", "Output file path")
+	exportCmd.Flags().MarkDeprecated("output", "use --file instead")
 	exportCmd.Flags().StringVarP(&exportFormat, "format", "t", "json", "Export format: json or txt")
 	exportCmd.Flags().StringVarP(&exportFolder, "folder", "f", "", "Export only notes from this folder")
+	exportCmd.Flags().StringVarP(&exportQuery, "query", "q", "", "Export only notes matching a search query")
 }
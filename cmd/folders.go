@@ -1,18 +1,24 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"strings"
 	"text/tabwriter"
 
+	"github.com/fishfisher/apple-notes/internal/applescript"
 	"github.com/fishfisher/apple-notes/internal/db"
 	"github.com/spf13/cobra"
 )
 
 var foldersCmd = &cobra.Command{
 	Use:   "folders",
-	Short: "List all folders",
-	Long:  `List all note folders with note counts.`,
+	Short: "List and manage folders",
+	Long: `List all note folders with note counts.
+
+Use the subcommands to create, rename, delete and move folders. Nested folders
+are addressed by path, e.g. "Work/Clients/New".`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		database, err := db.Open()
 		if err != nil {
@@ -25,6 +31,14 @@ var foldersCmd = &cobra.Command{
 			return fmt.Errorf("failed to list folders: %w", err)
 		}
 
+		if formatTemplate != "" {
+			return printFormatted(folders)
+		}
+
+		if structuredOutput() {
+			return printOutput(folderRecords(folders))
+		}
+
 		if len(folders) == 0 {
 			fmt.Println("No folders found")
 			return nil
@@ -43,3 +57,218 @@ var foldersCmd = &cobra.Command{
 		return nil
 	},
 }
+
+// systemFolders cannot be renamed, moved or deleted
+var systemFolders = []string{"Notes", "Recently Deleted", "Quick Notes"}
+
+var foldersDeleteMoveTo string
+
+var foldersCreateCmd = &cobra.Command{
+	Use:   "create [folder-path]",
+	Short: "Create a folder",
+	Long:  `Create a folder. Nested paths like "Work/Clients/New" create missing parent folders.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		folderPath := args[0]
+
+		exists, err := applescript.FolderExists(cmd.Context(), folderPath)
+		if err != nil {
+			return fmt.Errorf("failed to check folder: %w", err)
+		}
+		if exists {
+			return fmt.Errorf("folder '%s' already exists", folderPath)
+		}
+
+		database, err := db.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		defer database.Close()
+
+		statusf("Creating folder '%s'...\n", folderPath)
+		if err := applescript.CreateFolder(cmd.Context(), folderPath); err != nil {
+			return fmt.Errorf("failed to create folder: %w", err)
+		}
+
+		if err := verifyFolder(cmd.Context(), database, folderPath, true); err != nil {
+			return err
+		}
+
+		return reportWrite(folderResult("created", folderPath), "Folder created successfully")
+	},
+}
+
+var foldersRenameCmd = &cobra.Command{
+	Use:   "rename [folder-path] [new-name]",
+	Short: "Rename a folder",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		folderPath := args[0]
+		newName := args[1]
+
+		if err := checkFolderModifiable(cmd.Context(), folderPath); err != nil {
+			return err
+		}
+		if strings.Contains(newName, "/") {
+			return fmt.Errorf("new name cannot contain '/' (use 'folders move' to change the parent)")
+		}
+
+		database, err := db.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		defer database.Close()
+
+		statusf("Renaming folder '%s' to '%s'...\n", folderPath, newName)
+		if err := applescript.RenameFolder(cmd.Context(), folderPath, newName); err != nil {
+			return fmt.Errorf("failed to rename folder: %w", err)
+		}
+
+		parts := applescript.SplitFolderPath(folderPath)
+		newPath := strings.Join(append(parts[:len(parts)-1], newName), "/")
+		if err := verifyFolder(cmd.Context(), database, newPath, true); err != nil {
+			return err
+		}
+
+		return reportWrite(folderResult("renamed", newPath), "Folder renamed successfully")
+	},
+}
+
+var foldersDeleteCmd = &cobra.Command{
+	Use:   "delete [folder-path]",
+	Short: "Delete a folder",
+	Long: `Delete a folder. Folders that still contain notes are refused unless
+--move-notes-to is given, in which case the notes are moved there first.
+Folders with subfolders and system folders cannot be deleted.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		folderPath := args[0]
+
+		if err := checkFolderModifiable(cmd.Context(), folderPath); err != nil {
+			return err
+		}
+
+		notes, subfolders, err := applescript.CountFolderContents(cmd.Context(), folderPath)
+		if err != nil {
+			return fmt.Errorf("failed to inspect folder: %w", err)
+		}
+		if subfolders > 0 {
+			return fmt.Errorf("folder '%s' contains %d subfolders; delete or move them first", folderPath, subfolders)
+		}
+		if notes > 0 && foldersDeleteMoveTo == "" {
+			return fmt.Errorf("folder '%s' contains %d notes; use --move-notes-to to keep them", folderPath, notes)
+		}
+
+		database, err := db.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		defer database.Close()
+
+		if notes > 0 {
+			statusf("Moving %d notes from '%s' to '%s'...\n", notes, folderPath, foldersDeleteMoveTo)
+			if _, err := applescript.BulkMoveNotes(cmd.Context(), folderPath, foldersDeleteMoveTo); err != nil {
+				return fmt.Errorf("failed to move notes: %w", err)
+			}
+		}
+
+		statusf("Deleting folder '%s'...\n", folderPath)
+		if err := applescript.DeleteFolder(cmd.Context(), folderPath); err != nil {
+			return fmt.Errorf("failed to delete folder: %w", err)
+		}
+
+		if err := verifyFolder(cmd.Context(), database, folderPath, false); err != nil {
+			return err
+		}
+
+		return reportWrite(folderResult("deleted", folderPath), "Folder deleted successfully")
+	},
+}
+
+var foldersMoveCmd = &cobra.Command{
+	Use:   "move [folder-path] [new-parent]",
+	Short: "Move a folder into another folder",
+	Long:  `Move a folder into another folder. Use "/" as the new parent to move it to the top level.`,
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		folderPath := args[0]
+		newParent := args[1]
+
+		if err := checkFolderModifiable(cmd.Context(), folderPath); err != nil {
+			return err
+		}
+
+		source := strings.Join(applescript.SplitFolderPath(folderPath), "/")
+		target := strings.Join(applescript.SplitFolderPath(newParent), "/")
+		if target == source || strings.HasPrefix(target, source+"/") {
+			return fmt.Errorf("cannot move folder '%s' into itself", folderPath)
+		}
+
+		database, err := db.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		defer database.Close()
+
+		statusf("Moving folder '%s' to '%s'...\n", folderPath, newParent)
+		if err := applescript.MoveFolder(cmd.Context(), folderPath, newParent); err != nil {
+			return fmt.Errorf("failed to move folder: %w", err)
+		}
+
+		newPath := strings.Trim(target+"/"+folderName(folderPath), "/")
+		if err := verifyFolder(cmd.Context(), database, newPath, true); err != nil {
+			return err
+		}
+
+		return reportWrite(folderResult("moved", newPath), "Folder moved successfully")
+	},
+}
+
+// folderName returns the last component of a folder path, which is the name
+// notes report as their folder
+func folderName(folderPath string) string {
+	parts := applescript.SplitFolderPath(folderPath)
+	if len(parts) == 0 {
+		return folderPath
+	}
+	return parts[len(parts)-1]
+}
+
+// folderResult builds a writeResult for a folder operation
+func folderResult(action, folderPath string) writeResult {
+	return writeResult{Action: action, Folder: folderPath}
+}
+
+// checkFolderModifiable refuses system folders and folders that do not exist
+func checkFolderModifiable(ctx context.Context, folderPath string) error {
+	parts := applescript.SplitFolderPath(folderPath)
+	if len(parts) == 0 {
+		return fmt.Errorf("folder name cannot be empty")
+	}
+	if len(parts) == 1 {
+		for _, name := range systemFolders {
+			if strings.EqualFold(parts[0], name) {
+				return fmt.Errorf("'%s' is a system folder and cannot be modified", name)
+			}
+		}
+	}
+
+	exists, err := applescript.FolderExists(ctx, folderPath)
+	if err != nil {
+		return fmt.Errorf("failed to check folder: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("folder '%s' not found", folderPath)
+	}
+	return nil
+}
+
+func init() {
+	addFormatFlag(foldersCmd, "Name, Count")
+	foldersDeleteCmd.Flags().StringVar(&foldersDeleteMoveTo, "move-notes-to", "", "Move the folder's notes to this folder before deleting")
+
+	foldersCmd.AddCommand(foldersCreateCmd)
+	foldersCmd.AddCommand(foldersRenameCmd)
+	foldersCmd.AddCommand(foldersDeleteCmd)
+	foldersCmd.AddCommand(foldersMoveCmd)
+}
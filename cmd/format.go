@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/fishfisher/apple-notes/internal/db"
+	"github.com/spf13/cobra"
+)
+
+// formatTemplate is set by --format on listing commands
+var formatTemplate string
+
+// addFormatFlag registers --format on a listing command. fields is a short
+// description of the fields available to the template.
+func addFormatFlag(cmd *cobra.Command, fields string) {
+	cmd.Flags().StringVar(&formatTemplate, "format", "", "Format each item with a Go template ("+fields+")")
+}
+
+// formatFuncs are the helper functions available in --format templates:
+//
+//	{{date .Modified}} or {{date "Jan 2" .Modified}}  format a time
+//	{{truncate 20 .Title}}                           shorten to 20 characters
+//	{{json .}}                                       JSON, as with --output json
+//	{{upper .Folder}}                                upper case
+var formatFuncs = template.FuncMap{
+	"date": func(args ...interface{}) (string, error) {
+		layout := "2006-01-02 15:04"
+		switch len(args) {
+		case 1:
+		case 2:
+			s, ok := args[0].(string)
+			if !ok {
+				return "", fmt.Errorf("date: layout must be a string")
+			}
+			layout = s
+		default:
+			return "", fmt.Errorf("date: expected a time and an optional layout")
+		}
+		t, ok := args[len(args)-1].(time.Time)
+		if !ok {
+			return "", fmt.Errorf("date: %v is not a time", args[len(args)-1])
+		}
+		return t.Format(layout), nil
+	},
+	"truncate": truncateRunes,
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(formatRecord(v))
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	},
+	"upper": strings.ToUpper,
+}
+
+// formatRecord converts database types to the records used by --output so
+// that {{json .}} has the same field names
+func formatRecord(v interface{}) interface{} {
+	switch v := v.(type) {
+	case db.Note:
+		return newNoteRecord(v)
+	case db.Folder:
+		return folderRecords([]db.Folder{v})[0]
+	case db.Tag:
+		return tagRecords([]db.Tag{v})[0]
+	}
+	return v
+}
+
+// truncateRunes shortens s to at most n characters, ending with "..." when
+// it was cut. It never splits a multibyte character.
+func truncateRunes(n int, s string) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	if n <= 3 {
+		return string(runes[:n])
+	}
+	return string(runes[:n-3]) + "..."
+}
+
+// parseFormat parses the --format template. \t and \n are expanded so the
+// template can be written in single quotes on the command line.
+func parseFormat() (*template.Template, error) {
+	if structuredOutput() {
+		return nil, fmt.Errorf("--format cannot be combined with --output %s", outputFormat)
+	}
+	text := strings.NewReplacer(`\t`, "\t", `\n`, "\n").Replace(formatTemplate)
+	tmpl, err := template.New("format").Funcs(formatFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --format template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// printFormatted prints each item with the --format template, one per line
+func printFormatted[T any](items []T) error {
+	tmpl, err := parseFormat()
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		if err := tmpl.Execute(os.Stdout, item); err != nil {
+			return fmt.Errorf("failed to format output: %w", err)
+		}
+		fmt.Println()
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/fishfisher/apple-notes/internal/applescript"
+	"github.com/fishfisher/apple-notes/internal/db"
+	"github.com/spf13/cobra"
+)
+
+// dryRun is set by the global --dry-run flag
+var dryRun bool
+
+// installWriteGate puts the write gate in front of internal/applescript for
+// the command about to run. With --dry-run every write script is printed
+// instead of executed, so write commands need no dry-run handling of their own
+// beyond skipping confirmations and verification.
+func installWriteGate(cmd *cobra.Command) {
+	if !dryRun {
+		return
+	}
+	cmd.SetContext(applescript.WithDryRun(cmd.Context(), printPlannedScript))
+}
+
+// printPlannedScript prints a write script that was not executed
+func printPlannedScript(script string) {
+	out := os.Stdout
+	if structuredOutput() {
+		out = os.Stderr
+	}
+
+	lines := strings.Split(strings.Trim(script, "\n"), "\n")
+	indent := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		n := len(line) - len(strings.TrimLeft(line, "\t"))
+		if indent < 0 || n < indent {
+			indent = n
+		}
+	}
+
+	fmt.Fprintln(out, "[dry-run] Would run AppleScript:")
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		fmt.Fprintf(out, "    %s\n", strings.ReplaceAll(line[indent:], "\t", "    "))
+	}
+}
+
+// planWrite announces which notes a write is about to change. It only prints
+// in dry-run mode, where it lists the affected note IDs.
+func planWrite(action string, notes ...db.Note) {
+	if !dryRun {
+		return
+	}
+	for _, note := range notes {
+		statusf("[dry-run] Would %s note %s '%s' (folder '%s')\n", action, note.ID, note.Title, note.Folder)
+	}
+}
+
+// confirm asks a yes/no question. Dry runs change nothing, so they never ask.
+func confirm(prompt string) bool {
+	return confirmFrom(os.Stdin, prompt)
+}
+
+// confirmFrom asks a yes/no question, reading the answer from in
+func confirmFrom(in io.Reader, prompt string) bool {
+	if dryRun {
+		return true
+	}
+
+	// The prompt goes to stderr so it never mixes with structured output
+	fmt.Fprint(os.Stderr, prompt)
+	var response string
+	fmt.Fscanln(in, &response)
+	return response == "y" || response == "Y"
+}
@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/fatih/color"
+	"github.com/fishfisher/apple-notes/internal/db"
+	"github.com/fishfisher/apple-notes/internal/grep"
+	"github.com/spf13/cobra"
+)
+
+var (
+	grepIgnoreCase bool
+	grepContext    int
+	grepFilesOnly  bool
+	grepCount      bool
+	grepFolder     string
+	grepJobs       int
+)
+
+var grepCmd = &cobra.Command{
+	Use:   "grep <regex>",
+	Short: "Search note bodies line by line with a regular expression",
+	Long: `Search the full text of every note with a regular expression and print
+the matching lines, prefixed with the note ID, title and line number:
+
+  102 Incident: database outage:3:Root cause: disk full on primary
+
+Context lines (-C) are printed with - instead of :, and groups of lines that
+are not adjacent are separated by --. The first line of a note is its title.
+
+The expression uses Go's RE2 syntax (https://github.com/google/re2/wiki/Syntax).
+Notes are decoded and searched in parallel.`,
+	Example: `  apple-notes grep 'disk (full|space)'
+  apple-notes grep -i -C 2 todo
+  apple-notes grep -l '#incident' | apple-notes bulk move --to Archive`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if grepContext < 0 {
+			return fmt.Errorf("--context must not be negative")
+		}
+		if grepFilesOnly && grepCount {
+			return fmt.Errorf("--files-with-matches and --count cannot be combined")
+		}
+
+		pattern := args[0]
+		if grepIgnoreCase {
+			pattern = "(?i)" + pattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid regular expression: %w", err)
+		}
+
+		database, err := db.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		defer database.Close()
+
+		var notes []db.NoteData
+		if grepFolder != "" {
+			notes, err = database.ListNoteDataWhere("folders.ZTITLE2 = ?", folderName(grepFolder))
+		} else {
+			notes, err = database.ListNoteDataWhere("")
+		}
+		if err != nil {
+			return err
+		}
+
+		results := grep.Search(notes, re, grep.Options{Context: grepContext, Workers: grepJobs})
+
+		if structuredOutput() {
+			return printGrepRecords(results)
+		}
+
+		if len(results) == 0 {
+			fmt.Fprintf(os.Stderr, "No matches for '%s'\n", args[0])
+			return nil
+		}
+
+		printGrepResults(results)
+		return nil
+	},
+}
+
+// printGrepRecords prints the results in the --output format
+func printGrepRecords(results []grep.Result) error {
+	switch {
+	case grepFilesOnly:
+		notes := make([]db.Note, 0, len(results))
+		for _, result := range results {
+			notes = append(notes, result.Note)
+		}
+		return printOutput(noteRecords(notes))
+	case grepCount:
+		records := []grepCountRecord{}
+		for _, result := range results {
+			records = append(records, grepCountRecord{ID: result.Note.ID, Title: result.Note.Title, Folder: result.Note.Folder, Count: result.Matches})
+		}
+		return printOutput(records)
+	}
+
+	records := []grepLineRecord{}
+	for _, result := range results {
+		for _, line := range result.Lines {
+			records = append(records, grepLineRecord{
+				NoteID: result.Note.ID,
+				Title:  result.Note.Title,
+				Folder: result.Note.Folder,
+				Line:   line.Number,
+				Text:   line.Text,
+				Match:  line.Match,
+			})
+		}
+	}
+	return printOutput(records)
+}
+
+// printGrepResults prints the results grep style
+func printGrepResults(results []grep.Result) {
+	noteColor := color.New(color.FgMagenta)
+	numberColor := color.New(color.FgGreen)
+	matchColor := color.New(color.FgRed, color.Bold)
+
+	for i, result := range results {
+		prefix := noteColor.Sprintf("%s %s", result.Note.ID, result.Note.Title)
+		switch {
+		case grepFilesOnly:
+			fmt.Println(prefix)
+			continue
+		case grepCount:
+			fmt.Printf("%s:%d\n", prefix, result.Matches)
+			continue
+		}
+
+		if grepContext > 0 && i > 0 {
+			fmt.Println("--")
+		}
+		for j, line := range result.Lines {
+			if j > 0 && line.Number != result.Lines[j-1].Number+1 {
+				fmt.Println("--")
+			}
+
+			separator := "-"
+			if line.Match {
+				separator = ":"
+			}
+			fmt.Printf("%s%s%s%s%s\n", prefix, separator, numberColor.Sprint(line.Number), separator, highlightRanges(line.Text, line.Ranges, matchColor))
+		}
+	}
+}
+
+// highlightRanges colors the given byte ranges of text
+func highlightRanges(text string, ranges [][]int, c *color.Color) string {
+	if color.NoColor || len(ranges) == 0 {
+		return text
+	}
+
+	var out string
+	last := 0
+	for _, r := range ranges {
+		if r[0] == r[1] {
+			continue
+		}
+		out += text[last:r[0]] + c.Sprint(text[r[0]:r[1]])
+		last = r[1]
+	}
+	return out + text[last:]
+}
+
+func init() {
+	grepCmd.Flags().BoolVarP(&grepIgnoreCase, "ignore-case", "i", false, "Match case-insensitively")
+	grepCmd.Flags().IntVarP(&grepContext, "context", "C", 0, "Show this many lines before and after each match")
+	grepCmd.Flags().BoolVarP(&grepFilesOnly, "files-with-matches", "l", false, "Print only the notes that match")
+	grepCmd.Flags().BoolVarP(&grepCount, "count", "c", false, "Print the number of matching lines per note")
+	grepCmd.Flags().StringVarP(&grepFolder, "folder", "f", "", "Search only notes in this folder")
+	grepCmd.Flags().IntVarP(&grepJobs, "jobs", "j", 0, "Number of notes to search at once (default: number of CPUs)")
+}
@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fishfisher/apple-notes/internal/db"
+	"github.com/fishfisher/apple-notes/internal/index"
+	"github.com/spf13/cobra"
+)
+
+// indexChangesRecord is the structured output of index build and update
+type indexChangesRecord struct {
+	Added   int `json:"added"`
+	Updated int `json:"updated"`
+	Removed int `json:"removed"`
+	Total   int `json:"total"`
+}
+
+// indexStatusRecord is the structured output of index status
+type indexStatusRecord struct {
+	Path      string `json:"path"`
+	Available bool   `json:"available"`
+	Built     bool   `json:"built"`
+	Notes     int    `json:"notes"`
+	Stale     int    `json:"stale"`
+	Updated   string `json:"updated,omitempty"`
+}
+
+var indexCmd = &cobra.Command{
+	Use:   "index",
+	Short: "Manage the full-text search index",
+	Long: `Maintain an optional full-text search index of note titles, bodies and text
+recognized in attachments, used by 'apple-notes search --ranked'.
+
+The index is stored in ~/.cache/apple-notes/index.db (or
+$XDG_CACHE_HOME/apple-notes/index.db) and needs a build with FTS5
+support (go build -tags sqlite_fts5).`,
+}
+
+var indexBuildCmd = &cobra.Command{
+	Use:   "build",
+	Short: "Build the search index from scratch",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runIndexUpdate(true)
+	},
+}
+
+var indexUpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Index notes changed since the last update",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runIndexUpdate(false)
+	},
+}
+
+var indexStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the state of the search index",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := index.Path()
+		if err != nil {
+			return err
+		}
+
+		record := indexStatusRecord{Path: path, Available: index.Available(), Built: index.Exists(path)}
+		var updated time.Time
+		if record.Available && record.Built {
+			database, err := db.Open()
+			if err != nil {
+				return fmt.Errorf("failed to open database: %w", err)
+			}
+			defer database.Close()
+
+			ix, err := index.Open(path)
+			if err != nil {
+				return err
+			}
+			defer ix.Close()
+
+			status, err := ix.Status()
+			if err != nil {
+				return err
+			}
+			record.Notes = status.Notes
+			updated = status.Updated
+			if !updated.IsZero() {
+				record.Updated = formatRecordTime(updated)
+			}
+			if record.Stale, err = ix.Stale(database); err != nil {
+				return err
+			}
+		}
+
+		if structuredOutput() {
+			return printOutput(record)
+		}
+
+		fmt.Printf("Index:    %s\n", record.Path)
+		switch {
+		case !record.Available:
+			fmt.Printf("Status:   %v\n", index.ErrUnavailable)
+		case !record.Built:
+			fmt.Println("Status:   not built (run 'apple-notes index build')")
+		default:
+			fmt.Printf("Notes:    %d\n", record.Notes)
+			if !updated.IsZero() {
+				fmt.Printf("Updated:  %s\n", updated.Format("2006-01-02 15:04"))
+			}
+			if record.Stale > 0 {
+				fmt.Printf("Stale:    %d notes changed since the last update (run 'apple-notes index update')\n", record.Stale)
+			} else {
+				fmt.Println("Stale:    up to date")
+			}
+		}
+		return nil
+	},
+}
+
+// runIndexUpdate builds or updates the search index and reports the changes
+func runIndexUpdate(rebuild bool) error {
+	path, err := index.Path()
+	if err != nil {
+		return err
+	}
+
+	database, err := db.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer database.Close()
+
+	ix, err := index.Open(path)
+	if err != nil {
+		return err
+	}
+	defer ix.Close()
+
+	var changes index.Changes
+	if rebuild {
+		statusf("Building search index...\n")
+		changes, err = ix.Build(database)
+	} else {
+		changes, err = ix.Update(database)
+	}
+	if err != nil {
+		return err
+	}
+
+	status, err := ix.Status()
+	if err != nil {
+		return err
+	}
+
+	record := indexChangesRecord{Added: changes.Added, Updated: changes.Updated, Removed: changes.Removed, Total: status.Notes}
+	if structuredOutput() {
+		return printOutput(record)
+	}
+	fmt.Printf("Indexed %d notes (%d added, %d updated, %d removed)\n", record.Total, record.Added, record.Updated, record.Removed)
+	return nil
+}
+
+func init() {
+	indexCmd.AddCommand(indexBuildCmd)
+	indexCmd.AddCommand(indexUpdateCmd)
+	indexCmd.AddCommand(indexStatusCmd)
+}
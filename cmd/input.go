@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// stdinIsTerminal reports whether stdin is an interactive terminal
+func stdinIsTerminal() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// readInput reads all of stdin. The prompt is only shown when stdin is a
+// terminal, so piped input produces no prompt noise.
+func readInput(prompt string) (string, error) {
+	if stdinIsTerminal() {
+		fmt.Println(prompt)
+	}
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", fmt.Errorf("failed to read input: %w", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
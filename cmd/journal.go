@@ -0,0 +1,292 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/fishfisher/apple-notes/internal/applescript"
+	"github.com/fishfisher/apple-notes/internal/db"
+	"github.com/fishfisher/apple-notes/internal/journal"
+	"github.com/spf13/cobra"
+)
+
+var (
+	undoSteps    int
+	undoForce    bool
+	journalLimit int
+)
+
+var journalCmd = &cobra.Command{
+	Use:   "journal",
+	Short: "Inspect the undo journal",
+	Long: `Every write command records the previous state of the notes it changes in a
+local journal, so the change can be reversed with 'apple-notes undo'.`,
+}
+
+var journalListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List journaled write operations",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		entries, err := journal.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load journal: %w", err)
+		}
+
+		var shown []journal.Entry
+		for i := len(entries) - 1; i >= 0; i-- {
+			if entries[i].Action == journal.ActionUndo {
+				continue
+			}
+			shown = append(shown, entries[i])
+			if journalLimit > 0 && len(shown) >= journalLimit {
+				break
+			}
+		}
+
+		if len(shown) == 0 {
+			fmt.Println("Journal is empty")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SEQ\tTIME\tCOMMAND\tNOTE ID\tTITLE\tFOLDER\tSTATUS")
+		for _, entry := range shown {
+			status := "undoable"
+			if journal.IsUndone(entries, entry.Seq) {
+				status = "undone"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				entry.Seq,
+				entry.Time.Format("2006-01-02 15:04"),
+				entry.Command,
+				entry.NoteID,
+				entry.Title,
+				entry.Folder,
+				status,
+			)
+		}
+		w.Flush()
+
+		path, _ := journal.Path()
+		fmt.Printf("\nJournal: %s\n", path)
+		return nil
+	},
+}
+
+var undoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Undo recent write operations",
+	Long: `Reverse the most recent write operations recorded in the journal, newest
+first. Edits, appends and tags are reverted to the previous title and body,
+moves and archives are moved back, created notes are deleted, and deleted
+notes are recreated (with a new ID).`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		entries, err := journal.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load journal: %w", err)
+		}
+
+		pending := journal.Undoable(entries)
+		if len(pending) == 0 {
+			fmt.Println("Nothing to undo")
+			return nil
+		}
+		if undoSteps < 1 {
+			return fmt.Errorf("--steps must be at least 1")
+		}
+		if undoSteps < len(pending) {
+			pending = pending[:undoSteps]
+		}
+
+		fmt.Println("About to undo:")
+		for _, entry := range pending {
+			fmt.Printf("  #%d %s '%s' (%s)\n", entry.Seq, entry.Command, entry.Title, entry.Time.Format("2006-01-02 15:04"))
+		}
+
+		if !undoForce && !confirm("Continue? (y/N): ") {
+			statusf("Undo cancelled\n")
+			return nil
+		}
+
+		database, err := db.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		defer database.Close()
+
+		undone := 0
+		for _, entry := range pending {
+			fmt.Printf("Undoing #%d %s '%s'...\n", entry.Seq, entry.Command, entry.Title)
+			if entry.NoteID != "" {
+				planWrite("undo "+entry.Command+" on", db.Note{ID: entry.NoteID, Title: entry.Title, Folder: entry.Folder})
+			}
+			if err := undoEntry(cmd.Context(), database, entry); err != nil {
+				return fmt.Errorf("failed to undo #%d: %w", entry.Seq, err)
+			}
+			recordJournal(journal.Entry{
+				Command: "undo",
+				Action:  journal.ActionUndo,
+				NoteID:  entry.NoteID,
+				Title:   entry.Title,
+				Undoes:  entry.Seq,
+			})
+			undone++
+		}
+
+		if dryRun {
+			fmt.Printf("[dry-run] Would undo %d operation(s)\n", undone)
+			return nil
+		}
+		fmt.Printf("Successfully undid %d operation(s)\n", undone)
+		return nil
+	},
+}
+
+// undoEntry reverses a single journal entry
+func undoEntry(ctx context.Context, database *db.DB, entry journal.Entry) error {
+	if entry.Action == journal.ActionDelete {
+		lastID, err := database.MaxObjectID()
+		if err != nil {
+			return err
+		}
+		if err := applescript.RecreateNote(ctx, entry.Title, entry.Body, entry.Folder); err != nil {
+			return err
+		}
+		_, err = verifyCreated(ctx, database, entry.Title, entry.Folder, lastID)
+		return err
+	}
+
+	current, err := database.GetNote(entry.NoteID)
+	if err != nil {
+		if entry.Action == journal.ActionCreate {
+			// Already gone, nothing left to undo
+			return nil
+		}
+		return err
+	}
+
+	scriptID, err := database.AppleScriptID(current.ID)
+	if err != nil {
+		return err
+	}
+
+	switch entry.Action {
+	case journal.ActionCreate:
+		if err := applescript.DeleteNoteByID(ctx, scriptID); err != nil {
+			return err
+		}
+		return verifyDeleted(ctx, database, current.ID)
+	case journal.ActionUpdate:
+		before, err := database.ModificationStamp(current.ID)
+		if err != nil {
+			return err
+		}
+		if err := applescript.RestoreNoteContentByID(ctx, scriptID, entry.Title, entry.Body); err != nil {
+			return err
+		}
+		_, err = verifyModified(ctx, database, current.ID, before)
+		return err
+	case journal.ActionMove:
+		folder, err := database.NoteFolderPath(current.ID)
+		if err != nil {
+			return err
+		}
+		if folder == entry.Folder {
+			return nil
+		}
+		if err := applescript.MoveNoteByID(ctx, scriptID, entry.Folder); err != nil {
+			return err
+		}
+		_, err = verifyMoved(ctx, database, current.ID, entry.Folder)
+		return err
+	}
+
+	return fmt.Errorf("unsupported journal action: %s", entry.Action)
+}
+
+// snapshotNote captures the state of a note before a write: its full folder
+// path, and its body for actions that replace or remove it. The body is read
+// by ID, since another note may share the title.
+func snapshotNote(ctx context.Context, database *db.DB, command string, action journal.Action, note *db.Note) (journal.Entry, error) {
+	entry, err := journalEntry(database, command, action, note)
+	if err != nil {
+		return entry, err
+	}
+
+	if (action == journal.ActionUpdate || action == journal.ActionDelete) && !dryRun {
+		scriptID, err := database.AppleScriptID(note.ID)
+		if err != nil {
+			return entry, fmt.Errorf("failed to capture note for undo journal: %w", err)
+		}
+		body, err := applescript.GetNoteBodyByID(ctx, scriptID)
+		if err != nil {
+			return entry, fmt.Errorf("failed to capture note for undo journal: %w", err)
+		}
+		entry.Body = body
+	}
+
+	return entry, nil
+}
+
+// journalEntry is snapshotNote without the body, for commands that have
+// already read it
+func journalEntry(database *db.DB, command string, action journal.Action, note *db.Note) (journal.Entry, error) {
+	folder, err := database.NoteFolderPath(note.ID)
+	if err != nil {
+		return journal.Entry{}, fmt.Errorf("failed to capture note for undo journal: %w", err)
+	}
+	return journal.Entry{
+		Command: command,
+		Action:  action,
+		NoteID:  note.ID,
+		Title:   note.Title,
+		Folder:  folder,
+	}, nil
+}
+
+// recordCreate journals a note created by command and returns it. note is
+// the verified note, or nil when verification was skipped; the new note is
+// then looked up once without waiting, and the create is left out of the
+// journal with a warning if it has not shown up yet.
+func recordCreate(database *db.DB, command string, note *db.Note, title, folder string, afterID int64) *db.Note {
+	if dryRun {
+		return note
+	}
+	if note == nil {
+		found, err := database.FindNoteCreatedAfter(title, folder, afterID)
+		if err != nil || found == nil {
+			fmt.Fprintf(os.Stderr, "Warning: new note '%s' not found yet, so it was not recorded in the undo journal\n", title)
+			return nil
+		}
+		note = found
+	}
+
+	entry, err := journalEntry(database, command, journal.ActionCreate, note)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		return note
+	}
+	recordJournal(entry)
+	return note
+}
+
+// recordJournal appends entries to the undo journal. A failure to write the
+// journal does not fail the command that already changed the note.
+func recordJournal(entries ...journal.Entry) {
+	if dryRun {
+		return
+	}
+	if err := journal.Append(entries...); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to write undo journal: %v\n", err)
+	}
+}
+
+func init() {
+	journalListCmd.Flags().IntVarP(&journalLimit, "limit", "l", 20, "Maximum number of entries to show (0 = all)")
+	journalCmd.AddCommand(journalListCmd)
+
+	undoCmd.Flags().IntVarP(&undoSteps, "steps", "n", 1, "Number of operations to undo")
+	undoCmd.Flags().BoolVarP(&undoForce, "force", "y", false, "Skip confirmation prompt")
+}
@@ -2,8 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"os"
-	"text/tabwriter"
 
 	"github.com/fishfisher/apple-notes/internal/db"
 	"github.com/spf13/cobra"
@@ -24,29 +22,51 @@ var linksCmd = &cobra.Command{
 
 		if linksAll {
 			// Find all notes with links
-			notes, err := database.FindNotesWithLinks()
+			page, err := currentPage()
+			if err != nil {
+				return err
+			}
+
+			notes, total, err := database.FindNotesWithLinksPage(page)
 			if err != nil {
 				return fmt.Errorf("failed to find notes with links: %w", err)
 			}
 
+			if formatTemplate != "" {
+				return printFormatted(notes)
+			}
+
+			if structuredOutput() {
+				var records []linkRecord
+				for _, note := range notes {
+					urls, err := database.ExtractLinks(note.ID)
+					if err != nil {
+						return fmt.Errorf("failed to extract links: %w", err)
+					}
+					for _, url := range urls {
+						records = append(records, linkRecord{NoteID: note.ID, Title: note.Title, URL: url})
+					}
+				}
+				if records == nil {
+					records = []linkRecord{}
+				}
+				return printOutput(records)
+			}
+
 			if len(notes) == 0 {
 				fmt.Println("No notes with links found")
 				return nil
 			}
 
-			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-			fmt.Fprintln(w, "ID\tTITLE\tFOLDER\tMODIFIED")
-			for _, note := range notes {
-				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
-					note.ID,
-					note.Title,
-					note.Folder,
-					note.Modified.Format("2006-01-02 15:04"),
-				)
+			if err := printNoteTable(database, notes, shortNoteColumns); err != nil {
+				return err
 			}
-			w.Flush()
 
-			fmt.Printf("\nFound %d notes with links\n", len(notes))
+			if summary := pageSummary(page, len(notes), total); summary != "" {
+				fmt.Printf("\n%s with links\n", summary)
+			} else {
+				fmt.Printf("\nFound %d notes with links\n", len(notes))
+			}
 			return nil
 		}
 
@@ -56,9 +76,9 @@ var linksCmd = &cobra.Command{
 		}
 
 		noteID := args[0]
-		note, err := database.GetNote(noteID)
+		note, err := resolveNote(database, noteID)
 		if err != nil {
-			return fmt.Errorf("note not found: %w", err)
+			return err
 		}
 
 		urls, err := database.ExtractLinks(note.ID)
@@ -66,6 +86,17 @@ var linksCmd = &cobra.Command{
 			return fmt.Errorf("failed to extract links: %w", err)
 		}
 
+		if formatTemplate != "" || structuredOutput() {
+			records := []linkRecord{}
+			for _, url := range urls {
+				records = append(records, linkRecord{NoteID: note.ID, Title: note.Title, URL: url})
+			}
+			if formatTemplate != "" {
+				return printFormatted(records)
+			}
+			return printOutput(records)
+		}
+
 		if len(urls) == 0 {
 			fmt.Printf("No links found in note '%s'\n", note.Title)
 			return nil
@@ -82,4 +113,7 @@ var linksCmd = &cobra.Command{
 
 func init() {
 	linksCmd.Flags().BoolVarP(&linksAll, "all", "a", false, "Find all notes containing links")
+	addPageFlags(linksCmd)
+	addTableFlags(linksCmd, shortNoteColumns)
+	addFormatFlag(linksCmd, "NoteID, Title, URL; with --all ID, Title, Folder, Snippet, Created, Modified")
 }
@@ -2,8 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"os"
-	"text/tabwriter"
 
 	"github.com/fishfisher/apple-notes/internal/db"
 	"github.com/spf13/cobra"
@@ -11,8 +9,8 @@ import (
 
 var (
 	listFolder string
-	listLimit  int
 	listHideID bool
+	listIDs    bool
 )
 
 var listCmd = &cobra.Command{
@@ -26,58 +24,59 @@ var listCmd = &cobra.Command{
 		}
 		defer database.Close()
 
-		notes, err := database.ListNotes(listFolder)
+		page, err := currentPage()
+		if err != nil {
+			return err
+		}
+
+		notes, total, err := database.ListNotesPage(listFolder, page)
 		if err != nil {
 			return fmt.Errorf("failed to list notes: %w", err)
 		}
 
+		if formatTemplate != "" {
+			return printFormatted(notes)
+		}
+
+		if structuredOutput() {
+			return printOutput(noteRecords(notes))
+		}
+
 		if len(notes) == 0 {
 			fmt.Println("No notes found")
 			return nil
 		}
 
-		// Apply limit if specified
-		if listLimit > 0 && len(notes) > listLimit {
-			notes = notes[:listLimit]
+		if listIDs {
+			printNoteIDs(notes)
+			return nil
 		}
 
-		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		columns := defaultNoteColumns
+		if len(tableColumns) > 0 {
+			columns = tableColumns
+		}
 		if listHideID {
-			fmt.Fprintln(w, "TITLE\tFOLDER\tMODIFIED\tSNIPPET")
-		} else {
-			fmt.Fprintln(w, "ID\tTITLE\tFOLDER\tMODIFIED\tSNIPPET")
+			columns = withoutColumn(columns, "id")
 		}
-		for _, note := range notes {
-			snippet := note.Snippet
-			if len(snippet) > 60 {
-				snippet = snippet[:60] + "..."
-			}
-			if listHideID {
-				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
-					note.Title,
-					note.Folder,
-					note.Modified.Format("2006-01-02 15:04"),
-					snippet,
-				)
-			} else {
-				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
-					note.ID,
-					note.Title,
-					note.Folder,
-					note.Modified.Format("2006-01-02 15:04"),
-					snippet,
-				)
-			}
+		if err := printNoteTable(database, notes, columns); err != nil {
+			return err
 		}
-		w.Flush()
 
-		fmt.Printf("\nTotal: %d notes\n", len(notes))
+		if summary := pageSummary(page, len(notes), total); summary != "" {
+			fmt.Printf("\n%s\n", summary)
+		} else {
+			fmt.Printf("\nTotal: %d notes\n", len(notes))
+		}
 		return nil
 	},
 }
 
 func init() {
 	listCmd.Flags().StringVarP(&listFolder, "folder", "f", "", "Filter by folder name")
-	listCmd.Flags().IntVarP(&listLimit, "limit", "l", 0, "Limit number of results (0 = no limit)")
+	addPageFlags(listCmd)
 	listCmd.Flags().BoolVar(&listHideID, "hide-id", false, "Hide note IDs from output")
+	listCmd.Flags().BoolVar(&listIDs, "ids", false, "Print only note IDs, one per line")
+	addTableFlags(listCmd, defaultNoteColumns)
+	addFormatFlag(listCmd, "ID, Title, Folder, Snippet, Created, Modified")
 }
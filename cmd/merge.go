@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fishfisher/apple-notes/internal/applescript"
+	"github.com/fishfisher/apple-notes/internal/db"
+	"github.com/fishfisher/apple-notes/internal/journal"
+	"github.com/fishfisher/apple-notes/internal/markup"
+	"github.com/spf13/cobra"
+)
+
+var (
+	mergeInto        string
+	mergeYes         bool
+	mergeForceUnsafe bool
+)
+
+var mergeCmd = &cobra.Command{
+	Use:   "merge [note-id] [note-id]...",
+	Short: "Merge several notes into one",
+	Long: `Combine the bodies of several notes, in order of creation, into the oldest
+note (or the one chosen with --into), then move the other notes to Recently
+Deleted. Hashtags from all notes are kept in the merged body.
+
+The merged result is previewed before anything changes, and the merge can be
+reversed with 'apple-notes undo --steps N' (one step per note).`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		database, err := db.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		defer database.Close()
+
+		var notes []db.Note
+		seen := make(map[string]bool)
+		for _, ref := range args {
+			note, err := resolveNoteForWrite(database, ref)
+			if err != nil {
+				return err
+			}
+			if seen[note.ID] {
+				continue
+			}
+			seen[note.ID] = true
+
+			note.Body, err = database.GetNoteText(note.ID)
+			if err != nil {
+				note.Body = note.Snippet
+			}
+			notes = append(notes, *note)
+		}
+		if len(notes) < 2 {
+			return fmt.Errorf("at least two different notes are required")
+		}
+
+		sort.SliceStable(notes, func(i, j int) bool {
+			return notes[i].Created.Before(notes[j].Created)
+		})
+
+		target := &notes[0]
+		if mergeInto != "" {
+			into, err := resolveNoteForWrite(database, mergeInto)
+			if err != nil {
+				return err
+			}
+			target = nil
+			for i := range notes {
+				if notes[i].ID == into.ID {
+					target = &notes[i]
+				}
+			}
+			if target == nil {
+				return fmt.Errorf("--into %s must be one of the notes being merged", into.ID)
+			}
+		}
+
+		for _, note := range notes {
+			hasRichContent, err := database.HasRichContent(note.ID)
+			if err != nil {
+				return fmt.Errorf("failed to check note content: %w", err)
+			}
+			if hasRichContent && !mergeForceUnsafe {
+				return fmt.Errorf("note %s '%s' contains rich content that merging would destroy; merge it in Notes.app or use --force-unsafe", note.ID, note.Title)
+			}
+		}
+
+		// Preview
+		statusf("Merging %d notes into %s '%s':\n", len(notes), target.ID, target.Title)
+		for _, note := range notes {
+			statusf("  %s  %s  (%s, created %s)\n", note.ID, note.Title, note.Folder, note.Created.Format("2006-01-02 15:04"))
+		}
+
+		var texts []string
+		var tags []string
+		seenTags := make(map[string]bool)
+		for _, note := range notes {
+			texts = append(texts, strings.TrimSpace(markup.StripHashtags(note.Body)))
+			for _, tag := range db.Hashtags(note.Body) {
+				if !seenTags[strings.ToLower(tag)] {
+					seenTags[strings.ToLower(tag)] = true
+					tags = append(tags, tag)
+				}
+			}
+		}
+		// Each tag appears once, on a line of its own after the merged text
+		tagLine := strings.Join(tags, " ")
+		if tagLine != "" {
+			texts = append(texts, tagLine)
+		}
+		statusf("\n--- Merged content ---\n%s\n----------------------\n", strings.Join(texts, "\n\n"))
+
+		if !mergeYes && !confirm("Merge these notes? (y/N): ") {
+			statusf("Merge cancelled\n")
+			return nil
+		}
+
+		// Fetch the HTML bodies so formatting survives the merge
+		scriptIDs := make(map[string]string)
+		entries := make(map[string]journal.Entry)
+		var merged []string
+		for i, note := range notes {
+			scriptID, err := database.AppleScriptID(note.ID)
+			if err != nil {
+				return err
+			}
+			body, err := applescript.GetNoteBodyByID(cmd.Context(), scriptID)
+			if err != nil {
+				return err
+			}
+			action := journal.ActionDelete
+			if note.ID == target.ID {
+				action = journal.ActionUpdate
+			}
+			entry, err := journalEntry(database, "merge", action, &notes[i])
+			if err != nil {
+				return err
+			}
+			entry.Body = body
+			scriptIDs[note.ID] = scriptID
+			entries[note.ID] = entry
+			merged = append(merged, markup.StripHTMLHashtags(body))
+		}
+		if tagLine != "" {
+			merged = append(merged, markup.TextToHTML(tagLine))
+		}
+
+		before, err := database.ModificationStamp(target.ID)
+		if err != nil {
+			return err
+		}
+
+		planWrite("merge into", *target)
+		statusf("Updating note '%s'...\n", target.Title)
+		err = applescript.RestoreNoteContentByID(cmd.Context(), scriptIDs[target.ID], target.Title, strings.Join(merged, "<div><br></div>"))
+		if err != nil {
+			return fmt.Errorf("failed to update merged note: %w", err)
+		}
+		recordJournal(entries[target.ID])
+		if _, err := verifyModified(cmd.Context(), database, target.ID, before); err != nil {
+			return err
+		}
+
+		for _, note := range notes {
+			if note.ID == target.ID {
+				continue
+			}
+
+			planWrite("delete", note)
+			statusf("Deleting note '%s'...\n", note.Title)
+			if err := applescript.DeleteNoteByID(cmd.Context(), scriptIDs[note.ID]); err != nil {
+				return fmt.Errorf("failed to delete merged note %s: %w", note.ID, err)
+			}
+			recordJournal(entries[note.ID])
+			if err := verifyDeleted(cmd.Context(), database, note.ID); err != nil {
+				return err
+			}
+		}
+
+		return reportWrite(noteResult("merged", target), fmt.Sprintf("Merged %d notes successfully", len(notes)))
+	},
+}
+
+func init() {
+	mergeCmd.Flags().StringVar(&mergeInto, "into", "", "ID or ~title of the note to merge into (default: the oldest)")
+	mergeCmd.Flags().BoolVarP(&mergeYes, "yes", "y", false, "Skip confirmation prompt")
+	mergeCmd.Flags().BoolVar(&mergeForceUnsafe, "force-unsafe", false, "Merge notes with rich content (DANGEROUS: will destroy images/attachments)")
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/fishfisher/apple-notes/internal/applescript"
 	"github.com/fishfisher/apple-notes/internal/db"
+	"github.com/fishfisher/apple-notes/internal/journal"
 	"github.com/spf13/cobra"
 )
 
@@ -24,22 +25,38 @@ var moveCmd = &cobra.Command{
 		}
 		defer database.Close()
 
-		note, err := database.GetNote(noteID)
+		note, err := resolveNoteForWrite(database, noteID)
 		if err != nil {
-			return fmt.Errorf("note not found: %w", err)
+			return err
 		}
 
-		if note.Folder == targetFolder {
-			fmt.Printf("Note '%s' is already in folder '%s'\n", note.Title, targetFolder)
-			return nil
+		if note.Folder == folderName(targetFolder) {
+			statusf("Note '%s' is already in folder '%s'\n", note.Title, targetFolder)
+			return reportWrite(noteResult("unchanged", note), "Nothing to do")
 		}
 
-		fmt.Printf("Moving note '%s' from '%s' to '%s'...\n", note.Title, note.Folder, targetFolder)
-		if err := applescript.MoveNote(note.Title, targetFolder); err != nil {
+		scriptID, err := database.AppleScriptID(note.ID)
+		if err != nil {
+			return err
+		}
+
+		entry, err := snapshotNote(cmd.Context(), database, "move", journal.ActionMove, note)
+		if err != nil {
+			return err
+		}
+
+		planWrite("move", *note)
+		statusf("Moving note '%s' from '%s' to '%s'...\n", note.Title, note.Folder, targetFolder)
+		if err := applescript.MoveNoteByID(cmd.Context(), scriptID, targetFolder); err != nil {
 			return fmt.Errorf("failed to move note: %w", err)
 		}
+		recordJournal(entry)
+
+		note, err = verifyMoved(cmd.Context(), database, note.ID, targetFolder)
+		if err != nil {
+			return err
+		}
 
-		fmt.Println("Note moved successfully")
-		return nil
+		return reportWrite(noteResult("moved", note), "Note moved successfully")
 	},
 }
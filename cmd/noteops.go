@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/fishfisher/apple-notes/internal/applescript"
+	"github.com/fishfisher/apple-notes/internal/db"
+	"github.com/fishfisher/apple-notes/internal/journal"
+)
+
+// Per-note write operations shared by bulk and rules. Each one journals the
+// previous state, goes through the write gate and verifies the result.
+// Notes are addressed by ID, since titles are not unique.
+
+// moveNoteTo moves a note to folder, doing nothing if it is already there
+func moveNoteTo(ctx context.Context, database *db.DB, command string, note *db.Note, folder string) error {
+	if note.Folder == folderName(folder) {
+		return nil
+	}
+	scriptID, err := database.AppleScriptID(note.ID)
+	if err != nil {
+		return err
+	}
+	entry, err := snapshotNote(ctx, database, command, journal.ActionMove, note)
+	if err != nil {
+		return err
+	}
+	planWrite("move", *note)
+	if err := applescript.MoveNoteByID(ctx, scriptID, folder); err != nil {
+		return err
+	}
+	recordJournal(entry)
+	_, err = verifyMoved(ctx, database, note.ID, folder)
+	return err
+}
+
+// tagNote appends a hashtag to a note
+func tagNote(ctx context.Context, database *db.DB, command string, note *db.Note, tag string) error {
+	before, err := database.ModificationStamp(note.ID)
+	if err != nil {
+		return err
+	}
+	scriptID, err := database.AppleScriptID(note.ID)
+	if err != nil {
+		return err
+	}
+	entry, err := snapshotNote(ctx, database, command, journal.ActionUpdate, note)
+	if err != nil {
+		return err
+	}
+	planWrite("tag", *note)
+	if err := applescript.AddTagToNoteByID(ctx, scriptID, tag); err != nil {
+		return err
+	}
+	recordJournal(entry)
+	_, err = verifyModified(ctx, database, note.ID, before)
+	return err
+}
+
+// appendToNote appends text to a note's body
+func appendToNote(ctx context.Context, database *db.DB, command string, note *db.Note, text string) error {
+	before, err := database.ModificationStamp(note.ID)
+	if err != nil {
+		return err
+	}
+	scriptID, err := database.AppleScriptID(note.ID)
+	if err != nil {
+		return err
+	}
+	entry, err := snapshotNote(ctx, database, command, journal.ActionUpdate, note)
+	if err != nil {
+		return err
+	}
+	planWrite("append to", *note)
+	if err := applescript.AppendNoteByID(ctx, scriptID, text); err != nil {
+		return err
+	}
+	recordJournal(entry)
+	_, err = verifyModified(ctx, database, note.ID, before)
+	return err
+}
+
+// removeNote deletes a note
+func removeNote(ctx context.Context, database *db.DB, command string, note *db.Note) error {
+	scriptID, err := database.AppleScriptID(note.ID)
+	if err != nil {
+		return err
+	}
+	entry, err := snapshotNote(ctx, database, command, journal.ActionDelete, note)
+	if err != nil {
+		return err
+	}
+	planWrite("delete", *note)
+	if err := applescript.DeleteNoteByID(ctx, scriptID); err != nil {
+		return err
+	}
+	recordJournal(entry)
+	return verifyDeleted(ctx, database, note.ID)
+}
@@ -0,0 +1,294 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/fishfisher/apple-notes/internal/db"
+)
+
+// outputFormat is set by the global --output flag
+var outputFormat string
+
+// outputFormats are the values accepted by --output
+var outputFormats = []string{"text", "json", "ndjson", "csv", "yaml"}
+
+// validateOutputFormat checks the value of --output
+func validateOutputFormat() error {
+	for _, format := range outputFormats {
+		if outputFormat == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported output format: %s (use %s)", outputFormat, strings.Join(outputFormats, ", "))
+}
+
+// structuredOutput reports whether machine-readable output was requested
+func structuredOutput() bool {
+	return outputFormat != "text"
+}
+
+// statusf prints a progress message. With structured output it goes to
+// stderr so stdout stays machine-readable.
+func statusf(format string, a ...interface{}) {
+	if structuredOutput() {
+		fmt.Fprintf(os.Stderr, format, a...)
+		return
+	}
+	fmt.Printf(format, a...)
+}
+
+// printNoteIDs prints one note ID per line, for piping into bulk commands
+func printNoteIDs(notes []db.Note) {
+	for _, note := range notes {
+		fmt.Println(note.ID)
+	}
+}
+
+// printOutput writes v, a record or a slice of records, to stdout in the
+// selected structured format
+func printOutput(v interface{}) error {
+	switch outputFormat {
+	case "ndjson":
+		return printNDJSON(v)
+	case "csv":
+		return printCSV(v)
+	case "yaml":
+		return printYAML(v)
+	}
+	return printJSON(v)
+}
+
+// printJSON writes v to stdout as indented JSON
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// printNDJSON writes each element of a slice (or v itself) as one line of JSON
+func printNDJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return enc.Encode(v)
+	}
+	for i := 0; i < rv.Len(); i++ {
+		if err := enc.Encode(rv.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printCSV writes a slice of records (or a single record) as CSV with a
+// header row of JSON field names. Nested values are written as JSON.
+func printCSV(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	var rows []reflect.Value
+	elemType := rv.Type()
+	if rv.Kind() == reflect.Slice {
+		elemType = elemType.Elem()
+		for i := 0; i < rv.Len(); i++ {
+			rows = append(rows, rv.Index(i))
+		}
+	} else {
+		rows = append(rows, rv)
+	}
+	for elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return fmt.Errorf("csv output is not supported for this command")
+	}
+
+	fields := recordFields(elemType)
+	w := csv.NewWriter(os.Stdout)
+	header := make([]string, len(fields))
+	for i, field := range fields {
+		header[i] = field.name
+	}
+	if err := w.Write(header); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		row = reflect.Indirect(row)
+		record := make([]string, len(fields))
+		for i, field := range fields {
+			record[i] = csvCell(row.Field(field.index))
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// csvCell formats a field value for CSV
+func csvCell(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool, reflect.Int, reflect.Int64, reflect.Float64:
+		return fmt.Sprint(v.Interface())
+	case reflect.Ptr, reflect.Slice, reflect.Map:
+		if v.IsNil() {
+			return ""
+		}
+	}
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// printYAML writes v as YAML
+func printYAML(v interface{}) error {
+	lines := yamlLines(reflect.ValueOf(v))
+	_, err := fmt.Fprintln(os.Stdout, strings.Join(lines, "\n"))
+	return err
+}
+
+// recordField is an exported struct field with its JSON name
+type recordField struct {
+	name      string
+	index     int
+	omitEmpty bool
+}
+
+// recordFields returns the JSON-visible fields of a struct type in order
+func recordFields(t reflect.Type) []recordField {
+	var fields []recordField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, recordField{name: name, index: i, omitEmpty: strings.Contains(opts, "omitempty")})
+	}
+	return fields
+}
+
+// yamlLines renders a value as YAML lines, indented relative to the value
+func yamlLines(v reflect.Value) []string {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return []string{"null"}
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		var lines []string
+		for _, field := range recordFields(v.Type()) {
+			fv := v.Field(field.index)
+			if field.omitEmpty && fv.IsZero() {
+				continue
+			}
+			lines = append(lines, yamlEntry(field.name, fv)...)
+		}
+		if len(lines) == 0 {
+			return []string{"{}"}
+		}
+		return lines
+	case reflect.Map:
+		if v.Len() == 0 {
+			return []string{"{}"}
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		var lines []string
+		for _, key := range keys {
+			lines = append(lines, yamlEntry(yamlString(fmt.Sprint(key.Interface())), v.MapIndex(key))...)
+		}
+		return lines
+	case reflect.Slice, reflect.Array:
+		if v.Len() == 0 {
+			return []string{"[]"}
+		}
+		var lines []string
+		for i := 0; i < v.Len(); i++ {
+			item := yamlLines(v.Index(i))
+			lines = append(lines, "- "+item[0])
+			for _, line := range item[1:] {
+				lines = append(lines, "  "+line)
+			}
+		}
+		return lines
+	case reflect.String:
+		return []string{yamlString(v.String())}
+	}
+	return []string{fmt.Sprint(v.Interface())}
+}
+
+// yamlEntry renders "key: value", putting non-empty collections on indented
+// lines below the key
+func yamlEntry(key string, v reflect.Value) []string {
+	lines := yamlLines(v)
+	if len(lines) == 1 && (!yamlCollection(v) || lines[0] == "{}" || lines[0] == "[]") {
+		return []string{key + ": " + lines[0]}
+	}
+	entry := []string{key + ":"}
+	for _, line := range lines {
+		entry = append(entry, "  "+line)
+	}
+	return entry
+}
+
+// yamlCollection reports whether v is a struct, map or slice
+func yamlCollection(v reflect.Value) bool {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return false
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		return true
+	}
+	return false
+}
+
+// yamlString returns s as a plain scalar when that is unambiguous, and as a
+// double-quoted scalar otherwise
+func yamlString(s string) string {
+	plain := s != "" &&
+		strings.TrimSpace(s) == s &&
+		!strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@`") &&
+		!strings.Contains(s, ": ") &&
+		!strings.Contains(s, " #") &&
+		!strings.ContainsAny(s, "\n\t\\") &&
+		!strings.HasSuffix(s, ":")
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "null", "~":
+		plain = false
+	}
+	if plain && strings.IndexFunc(s[:1], func(r rune) bool { return r >= '0' && r <= '9' || r == '.' || r == '+' }) == 0 {
+		plain = false
+	}
+	if plain {
+		return s
+	}
+	data, _ := json.Marshal(s)
+	return string(data)
+}
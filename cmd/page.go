@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/fishfisher/apple-notes/internal/db"
+	"github.com/spf13/cobra"
+)
+
+// Set by the paging flags on commands that list notes
+var (
+	pageLimit  int
+	pageOffset int
+	pageNumber int
+)
+
+// defaultPageSize is the page size for --page without --limit
+const defaultPageSize = 50
+
+// addPageFlags registers --limit, --offset and --page on a command
+func addPageFlags(cmd *cobra.Command) {
+	cmd.Flags().IntVarP(&pageLimit, "limit", "l", 0, "Limit number of results (0 = no limit)")
+	cmd.Flags().IntVar(&pageOffset, "offset", 0, "Skip this many results")
+	cmd.Flags().IntVar(&pageNumber, "page", 0, fmt.Sprintf("Show this page of results (%d per page unless --limit is set)", defaultPageSize))
+}
+
+// currentPage returns the page selected by the paging flags, ordered by
+// --sort and --reverse
+func currentPage() (db.Page, error) {
+	page := db.Page{Sort: tableSort, Reverse: tableReverse, Limit: pageLimit, Offset: pageOffset}
+	if pageLimit < 0 || pageOffset < 0 || pageNumber < 0 {
+		return page, fmt.Errorf("--limit, --offset and --page cannot be negative")
+	}
+	if pageNumber > 0 {
+		if pageOffset > 0 {
+			return page, fmt.Errorf("--page cannot be combined with --offset")
+		}
+		if page.Limit == 0 {
+			page.Limit = defaultPageSize
+		}
+		page.Offset = (pageNumber - 1) * page.Limit
+	}
+	return page, nil
+}
+
+// pageSummary describes which of total results are shown, e.g.
+// "Showing 51-100 of 312 notes". It is empty when all results are shown.
+func pageSummary(page db.Page, shown, total int) string {
+	if shown == total {
+		return ""
+	}
+	if shown == 0 {
+		return fmt.Sprintf("Showing 0 of %d notes", total)
+	}
+	return fmt.Sprintf("Showing %d-%d of %d notes", page.Offset+1, page.Offset+shown, total)
+}
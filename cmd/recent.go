@@ -2,8 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"os"
-	"text/tabwriter"
 
 	"github.com/fishfisher/apple-notes/internal/db"
 	"github.com/spf13/cobra"
@@ -43,27 +41,26 @@ var recentCmd = &cobra.Command{
 			return fmt.Errorf("failed to get recent notes: %w", err)
 		}
 
+		if err := sortNotes(database, notes, tableSort, tableReverse); err != nil {
+			return err
+		}
+
+		if formatTemplate != "" {
+			return printFormatted(notes)
+		}
+
+		if structuredOutput() {
+			return printOutput(noteRecords(notes))
+		}
+
 		if len(notes) == 0 {
 			fmt.Println("No recent notes found")
 			return nil
 		}
 
-		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		fmt.Fprintln(w, "ID\tTITLE\tFOLDER\tMODIFIED\tSNIPPET")
-		for _, note := range notes {
-			snippet := note.Snippet
-			if len(snippet) > 60 {
-				snippet = snippet[:60] + "..."
-			}
-			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
-				note.ID,
-				note.Title,
-				note.Folder,
-				note.Modified.Format("2006-01-02 15:04"),
-				snippet,
-			)
+		if err := printNoteTable(database, notes, defaultNoteColumns); err != nil {
+			return err
 		}
-		w.Flush()
 
 		periodStr := fmt.Sprintf("last %d days", days)
 		if recentToday {
@@ -80,4 +77,6 @@ func init() {
 	recentCmd.Flags().BoolVar(&recentToday, "today", false, "Show notes modified today")
 	recentCmd.Flags().BoolVar(&recentWeek, "week", false, "Show notes modified this week")
 	recentCmd.Flags().IntVarP(&recentLimit, "limit", "l", 20, "Maximum number of notes to show")
+	addTableFlags(recentCmd, defaultNoteColumns)
+	addFormatFlag(recentCmd, "ID, Title, Folder, Snippet, Created, Modified")
 }
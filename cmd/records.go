@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/fishfisher/apple-notes/internal/db"
+	"github.com/fishfisher/apple-notes/internal/dedupe"
+	"github.com/fishfisher/apple-notes/internal/index"
+	"github.com/fishfisher/apple-notes/internal/related"
+)
+
+// Records are the stable machine-readable forms of the data read commands
+// print with --output json|ndjson|csv|yaml. Field names are part of the CLI's
+// interface: add fields freely, but do not rename or remove them.
+
+// noteRecord is a note. Dates are RFC 3339 in local time.
+type noteRecord struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	Folder   string `json:"folder"`
+	Created  string `json:"created"`
+	Modified string `json:"modified"`
+	Snippet  string `json:"snippet"`
+	Body     string `json:"body,omitempty"`
+}
+
+// searchHitRecord is a ranked search result. Lower scores rank higher;
+// matched words in the snippet are marked with **.
+type searchHitRecord struct {
+	ID       string  `json:"id"`
+	Title    string  `json:"title"`
+	Folder   string  `json:"folder"`
+	Modified string  `json:"modified"`
+	Score    float64 `json:"score"`
+	Snippet  string  `json:"snippet"`
+}
+
+// grepLineRecord is a line printed by grep. Context lines have match false.
+type grepLineRecord struct {
+	NoteID string `json:"note_id"`
+	Title  string `json:"title"`
+	Folder string `json:"folder"`
+	Line   int    `json:"line"`
+	Text   string `json:"text"`
+	Match  bool   `json:"match"`
+}
+
+// grepCountRecord is the number of matching lines in a note (grep --count)
+type grepCountRecord struct {
+	ID     string `json:"id"`
+	Title  string `json:"title"`
+	Folder string `json:"folder"`
+	Count  int    `json:"count"`
+}
+
+// relatedRecord is a note similar to another. Score is the cosine
+// similarity (0-1); terms are the words the notes share that count most.
+type relatedRecord struct {
+	ID       string   `json:"id"`
+	Title    string   `json:"title"`
+	Folder   string   `json:"folder"`
+	Modified string   `json:"modified"`
+	Score    float64  `json:"score"`
+	Terms    []string `json:"terms"`
+}
+
+// folderRecord is a folder with its note count
+type folderRecord struct {
+	Name      string `json:"name"`
+	NoteCount int    `json:"note_count"`
+}
+
+// tagRecord is a hashtag with the number of notes using it
+type tagRecord struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// linkRecord is a URL found in a note
+type linkRecord struct {
+	NoteID string `json:"note_id"`
+	Title  string `json:"title"`
+	URL    string `json:"url"`
+}
+
+// statsRecord summarizes the collection
+type statsRecord struct {
+	TotalNotes            int         `json:"total_notes"`
+	TotalFolders          int         `json:"total_folders"`
+	ModifiedThisWeek      int         `json:"modified_this_week"`
+	ModifiedThisMonth     int         `json:"modified_this_month"`
+	LargestNote           *noteRecord `json:"largest_note,omitempty"`
+	LargestNoteCharacters int64       `json:"largest_note_characters"`
+	TopTags               []tagRecord `json:"top_tags"`
+}
+
+// duplicateGroupRecord is a set of duplicate or similar notes. Title is set
+// for identical titles, Pairs for similar content.
+type duplicateGroupRecord struct {
+	Title string             `json:"title,omitempty"`
+	Notes []noteRecord       `json:"notes"`
+	Pairs []similarityRecord `json:"pairs,omitempty"`
+}
+
+// similarityRecord is the content similarity of two notes, from 0 to 1
+type similarityRecord struct {
+	A          string  `json:"a"`
+	B          string  `json:"b"`
+	Similarity float64 `json:"similarity"`
+}
+
+func newNoteRecord(note db.Note) noteRecord {
+	return noteRecord{
+		ID:       note.ID,
+		Title:    note.Title,
+		Folder:   note.Folder,
+		Created:  formatRecordTime(note.Created),
+		Modified: formatRecordTime(note.Modified),
+		Snippet:  note.Snippet,
+		Body:     note.Body,
+	}
+}
+
+func noteRecords(notes []db.Note) []noteRecord {
+	records := make([]noteRecord, 0, len(notes))
+	for _, note := range notes {
+		records = append(records, newNoteRecord(note))
+	}
+	return records
+}
+
+func folderRecords(folders []db.Folder) []folderRecord {
+	records := make([]folderRecord, 0, len(folders))
+	for _, folder := range folders {
+		records = append(records, folderRecord{Name: folder.Name, NoteCount: folder.Count})
+	}
+	return records
+}
+
+func tagRecords(tags []db.Tag) []tagRecord {
+	records := make([]tagRecord, 0, len(tags))
+	for _, tag := range tags {
+		records = append(records, tagRecord{Tag: tag.Name, Count: tag.Count})
+	}
+	return records
+}
+
+func newStatsRecord(stats *db.Stats) statsRecord {
+	record := statsRecord{
+		TotalNotes:            stats.TotalNotes,
+		TotalFolders:          stats.TotalFolders,
+		ModifiedThisWeek:      stats.NotesThisWeek,
+		ModifiedThisMonth:     stats.NotesThisMonth,
+		LargestNoteCharacters: stats.TotalCharacters,
+		TopTags:               tagRecords(stats.TopTags),
+	}
+	if stats.LargestNote.Title != "" {
+		largest := newNoteRecord(stats.LargestNote)
+		record.LargestNote = &largest
+	}
+	return record
+}
+
+func newSearchHitRecord(note db.Note, hit index.Hit) searchHitRecord {
+	return searchHitRecord{
+		ID:       note.ID,
+		Title:    note.Title,
+		Folder:   note.Folder,
+		Modified: formatRecordTime(note.Modified),
+		Score:    hit.Score,
+		Snippet:  hit.Snippet,
+	}
+}
+
+func newRelatedRecord(note db.Note, match related.Match) relatedRecord {
+	terms := match.Terms
+	if terms == nil {
+		terms = []string{}
+	}
+	return relatedRecord{
+		ID:       note.ID,
+		Title:    note.Title,
+		Folder:   note.Folder,
+		Modified: formatRecordTime(note.Modified),
+		Score:    match.Score,
+		Terms:    terms,
+	}
+}
+
+func similarityRecords(pairs []dedupe.Pair) []similarityRecord {
+	records := make([]similarityRecord, 0, len(pairs))
+	for _, pair := range pairs {
+		records = append(records, similarityRecord{A: pair.A, B: pair.B, Similarity: pair.Similarity})
+	}
+	return records
+}
+
+// formatRecordTime formats a time as RFC 3339, or "" if it is unknown
+func formatRecordTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fishfisher/apple-notes/internal/db"
+	"github.com/fishfisher/apple-notes/internal/related"
+	"github.com/spf13/cobra"
+)
+
+var (
+	relatedLimit int
+	relatedIDs   bool
+)
+
+var relatedCmd = &cobra.Command{
+	Use:   "related [note-id]",
+	Short: "Find notes similar to a note",
+	Long: `List the notes whose content is most similar to a note, for example
+earlier incident notes when writing up a new one.
+
+Notes are compared by their words, weighted with TF-IDF so that words that
+are rare in the collection count the most, and ranked by cosine similarity.
+Common English and Norwegian words are ignored, as are case and accents.
+Everything runs locally.
+
+Word counts are cached in ~/.cache/apple-notes/related.json (or
+$XDG_CACHE_HOME/apple-notes) and refreshed for notes modified since they
+were cached.`,
+	Example: `  apple-notes related 4318
+  apple-notes related ~"database outage" --limit 5`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if relatedLimit < 0 {
+			return fmt.Errorf("--limit must not be negative")
+		}
+
+		database, err := db.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		defer database.Close()
+
+		note, err := resolveNote(database, args[0])
+		if err != nil {
+			return err
+		}
+
+		notes, err := database.ListNotes("")
+		if err != nil {
+			return fmt.Errorf("failed to list notes: %w", err)
+		}
+		byID := make(map[string]db.Note, len(notes))
+		for _, n := range notes {
+			byID[n.ID] = n
+		}
+
+		path, err := related.Path()
+		if err != nil {
+			return err
+		}
+		cache, err := related.Load(path)
+		if err != nil {
+			return err
+		}
+		if cache.Empty() {
+			statusf("Analyzing %d notes...\n", len(notes))
+		}
+		counted, err := cache.Update(database, notes)
+		if err != nil {
+			return err
+		}
+		if counted > 0 {
+			if err := cache.Save(); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			}
+		}
+
+		matches, err := cache.Similar(note.ID, relatedLimit)
+		if err != nil {
+			return err
+		}
+
+		var similar []db.Note
+		var records []relatedRecord
+		for _, match := range matches {
+			n := byID[match.ID]
+			similar = append(similar, n)
+			records = append(records, newRelatedRecord(n, match))
+		}
+
+		if relatedIDs {
+			printNoteIDs(similar)
+			return nil
+		}
+
+		if structuredOutput() {
+			if records == nil {
+				records = []relatedRecord{}
+			}
+			return printOutput(records)
+		}
+
+		if len(records) == 0 {
+			fmt.Printf("No notes related to '%s'\n", note.Title)
+			return nil
+		}
+
+		fmt.Printf("Notes related to '%s':\n\n", note.Title)
+		t := &table{columns: []tableColumn{
+			{Header: "ID"},
+			{Header: "TITLE", MaxWidth: 40, Flexible: true},
+			{Header: "FOLDER", MaxWidth: 20, Flexible: true},
+			{Header: "SCORE"},
+			{Header: "SHARED TERMS", Flexible: true},
+		}}
+		for _, record := range records {
+			t.addRow(record.ID, record.Title, record.Folder, fmt.Sprintf("%.0f%%", record.Score*100), strings.Join(record.Terms, ", "))
+		}
+		t.render(os.Stdout, terminalWidth())
+		return nil
+	},
+}
+
+func init() {
+	relatedCmd.Flags().IntVarP(&relatedLimit, "limit", "l", 10, "Maximum number of notes to show (0 = all)")
+	relatedCmd.Flags().BoolVar(&relatedIDs, "ids", false, "Print only note IDs, one per line")
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fishfisher/apple-notes/internal/db"
+	"github.com/fishfisher/apple-notes/internal/fuzzy"
+)
+
+// maxListedCandidates limits the notes listed when a title query is
+// ambiguous
+const maxListedCandidates = 10
+
+// resolveNote returns the note a [note-id] argument refers to: a note ID,
+// or ~ followed by part of a title, matched fuzzily. When several notes
+// match equally well, the user picks one on a terminal; otherwise the
+// candidates are listed in the error.
+func resolveNote(database *db.DB, ref string) (*db.Note, error) {
+	return resolveNoteRef(database, ref, false)
+}
+
+// resolveNoteForWrite is resolveNote for commands that change the note. A
+// title that matches only loosely (letters in order, or a likely typo) must
+// be confirmed on a terminal and is refused otherwise, so a typo never
+// silently picks the note to change.
+func resolveNoteForWrite(database *db.DB, ref string) (*db.Note, error) {
+	return resolveNoteRef(database, ref, true)
+}
+
+func resolveNoteRef(database *db.DB, ref string, write bool) (*db.Note, error) {
+	query, isQuery := strings.CutPrefix(ref, "~")
+	if !isQuery {
+		return database.GetNote(ref)
+	}
+	if strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("empty title query: use ~ followed by part of a title, e.g. ~standup")
+	}
+
+	notes, err := database.FindNotesByTitle(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to match note titles: %w", err)
+	}
+	switch len(notes) {
+	case 0:
+		return nil, fmt.Errorf("no note title matches '%s'", query)
+	case 1:
+		if write && fuzzy.Loose(fuzzy.Score(query, notes[0].Title)) {
+			return confirmLooseMatch(query, &notes[0])
+		}
+		return &notes[0], nil
+	}
+
+	if len(notes) > maxListedCandidates {
+		notes = notes[:maxListedCandidates]
+	}
+	options := make([]string, len(notes))
+	for i, note := range notes {
+		options[i] = candidateLabel(&note)
+	}
+
+	if !stdinIsTerminal() {
+		return nil, fmt.Errorf("'%s' matches several notes; use a note ID or a more specific title:\n  %s",
+			query, strings.Join(options, "\n  "))
+	}
+
+	i := choose(fmt.Sprintf("Several notes match '%s':", query), options)
+	if i < 0 {
+		return nil, fmt.Errorf("no note selected")
+	}
+	return &notes[i], nil
+}
+
+// confirmLooseMatch asks whether a loosely matched note is the one meant.
+// The question goes to stderr so it never mixes with structured output.
+func confirmLooseMatch(query string, note *db.Note) (*db.Note, error) {
+	if !stdinIsTerminal() {
+		return nil, fmt.Errorf("'%s' only loosely matches %s; use the note ID or a title it contains",
+			query, candidateLabel(note))
+	}
+
+	fmt.Fprintf(os.Stderr, "'%s' only loosely matches %s\nUse this note? (y/N): ", query, candidateLabel(note))
+	line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	if answer := strings.TrimSpace(line); answer != "y" && answer != "Y" {
+		return nil, fmt.Errorf("no note selected")
+	}
+	return note, nil
+}
+
+// candidateLabel describes a note well enough to tell similar titles apart
+func candidateLabel(note *db.Note) string {
+	return fmt.Sprintf("%s  %s (%s, %s)", note.ID, note.Title, note.Folder, note.Modified.Format("2006-01-02 15:04"))
+}
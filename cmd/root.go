@@ -1,9 +1,13 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"time"
 
+	"github.com/fishfisher/apple-notes/internal/applescript"
+	"github.com/fishfisher/apple-notes/internal/config"
 	"github.com/spf13/cobra"
 )
 
@@ -17,7 +21,20 @@ var rootCmd = &cobra.Command{
 	Use:   "apple-notes",
 	Short: "CLI for Apple Notes",
 	Long: `apple-notes is a command-line interface for Apple Notes.
-It uses SQLite for fast read operations and AppleScript for write operations.`,
+It uses SQLite for fast read operations and AppleScript for write operations.
+
+Commands that take a [note-id] also accept ~ followed by part of a title,
+e.g. 'apple-notes show ~standup'.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := applyFlagDefaults(cmd); err != nil {
+			return err
+		}
+		if err := validateOutputFormat(); err != nil {
+			return err
+		}
+		installWriteGate(cmd)
+		return nil
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		cmd.Help()
 	},
@@ -30,17 +47,68 @@ func SetVersionInfo(v, c, d string) {
 	rootCmd.Version = fmt.Sprintf("%s (commit: %s, built: %s)", version, commit, date)
 }
 
+// Exit codes for AppleScript failures, so scripts can react without parsing
+// error messages. Any other failure exits with 1.
+const (
+	exitPermissionDenied = 3
+	exitNotFound         = 4
+	exitAppNotRunning    = 5
+	exitTimeout          = 6
+)
+
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	loaded, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	cfg = loaded
+
+	args, err := expandAlias(os.Args[1:])
+	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
+	rootCmd.SetArgs(args)
+
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+
+		var scriptErr *applescript.Error
+		if errors.As(err, &scriptErr) && scriptErr.Hint() != "" {
+			fmt.Fprintf(os.Stderr, "Hint: %s\n", scriptErr.Hint())
+		}
+		os.Exit(exitCode(err))
+	}
+}
+
+// exitCode maps an error to the process exit code
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, applescript.ErrPermissionDenied):
+		return exitPermissionDenied
+	case errors.Is(err, applescript.ErrNotFound):
+		return exitNotFound
+	case errors.Is(err, applescript.ErrAppNotRunning):
+		return exitAppNotRunning
+	case errors.Is(err, applescript.ErrTimeout):
+		return exitTimeout
+	}
+	return 1
 }
 
 func init() {
 	// Enable -v as shorthand for --version
 	rootCmd.Flags().BoolP("version", "v", false, "version for apple-notes")
 
+	// Output
+	rootCmd.PersistentFlags().StringVar(&outputFormat, "output", "text", "Output format: text, json, ndjson, csv or yaml")
+
+	// AppleScript execution
+	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "Show what write commands would change without changing anything")
+	rootCmd.PersistentFlags().DurationVar(&applescript.Timeout, "timeout", applescript.Timeout, "Timeout for each AppleScript call")
+	rootCmd.PersistentFlags().IntVar(&applescript.MaxRetries, "retries", applescript.MaxRetries, "Retries for transient AppleScript failures")
+	rootCmd.PersistentFlags().DurationVar(&verifyTimeout, "verify-timeout", 10*time.Second, "How long to wait for writes to show up in the database (0 disables)")
+
 	// Read operations
 	rootCmd.AddCommand(listCmd)
 	rootCmd.AddCommand(searchCmd)
@@ -50,14 +118,20 @@ func init() {
 	rootCmd.AddCommand(recentCmd)
 	rootCmd.AddCommand(statsCmd)
 	rootCmd.AddCommand(duplicatesCmd)
+	rootCmd.AddCommand(mergeCmd)
 	rootCmd.AddCommand(linksCmd)
+	rootCmd.AddCommand(indexCmd)
+	rootCmd.AddCommand(grepCmd)
+	rootCmd.AddCommand(relatedCmd)
 
 	// Write operations
 	rootCmd.AddCommand(addCmd)
+	rootCmd.AddCommand(captureCmd)
 	rootCmd.AddCommand(editCmd)
 	rootCmd.AddCommand(deleteCmd)
 	rootCmd.AddCommand(moveCmd)
 	rootCmd.AddCommand(appendCmd)
+	rootCmd.AddCommand(dailyCmd)
 
 	// Tags
 	rootCmd.AddCommand(tagsCmd)
@@ -65,6 +139,8 @@ func init() {
 	// Bulk operations
 	rootCmd.AddCommand(bulkCmd)
 	rootCmd.AddCommand(archiveCmd)
+	rootCmd.AddCommand(unarchiveCmd)
+	rootCmd.AddCommand(rulesCmd)
 
 	// Templates
 	rootCmd.AddCommand(templateCmd)
@@ -73,6 +149,11 @@ func init() {
 	rootCmd.AddCommand(backupCmd)
 	rootCmd.AddCommand(restoreCmd)
 
+	// Undo
+	rootCmd.AddCommand(undoCmd)
+	rootCmd.AddCommand(journalCmd)
+
 	// Other
+	rootCmd.AddCommand(configCmd)
 	rootCmd.AddCommand(versionCmd)
 }
@@ -0,0 +1,347 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fishfisher/apple-notes/internal/db"
+	"github.com/fishfisher/apple-notes/internal/query"
+	"github.com/fishfisher/apple-notes/internal/rules"
+	"github.com/spf13/cobra"
+)
+
+var (
+	rulesFile string
+	rulesYes  bool
+)
+
+var rulesCmd = &cobra.Command{
+	Use:   "rules",
+	Short: "Organize notes with declarative rules",
+	Long: `Apply housekeeping rules from a JSON file (default: ~/.config/apple-notes/rules.json).
+
+Each rule has conditions, which must all match, and actions, which are applied
+in order to every matching note. Rules are evaluated in file order and see the
+effect of earlier rules, so a note moved by one rule is in its new folder for
+the next, and a deleted note is skipped.
+
+Conditions:
+  folder                  Folder name
+  tag                     Hashtag in the note body
+  title_regex             Regular expression matched against the title
+  created_older_than      Age such as 30d, 2w, 6mo or 1y
+  created_newer_than
+  modified_older_than
+  modified_newer_than
+  min_size, max_size      Body length in characters
+  has_attachments         true or false
+  pinned                  true or false
+
+Actions:
+  {"type": "move", "folder": "Archive"}
+  {"type": "tag", "tag": "#stale"}
+  {"type": "append", "text": "Reviewed"}
+  {"type": "delete"}
+
+Example:
+  {
+    "rules": [
+      {
+        "name": "archive stale work notes",
+        "conditions": {"folder": "Work", "modified_older_than": "6mo", "pinned": false},
+        "actions": [{"type": "tag", "tag": "#archived"}, {"type": "move", "folder": "Archive"}]
+      }
+    ]
+  }`,
+}
+
+var rulesCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Show which notes the rules would change",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		database, matches, err := loadRuleMatches()
+		if err != nil {
+			return err
+		}
+		defer database.Close()
+
+		if structuredOutput() {
+			return printOutput(ruleMatchResults(matches))
+		}
+		if len(matches) == 0 {
+			fmt.Println("No notes match any rule")
+			return nil
+		}
+		printRuleMatches(matches)
+		fmt.Printf("\n%d actions on %d notes\n", countRuleActions(matches), countRuleNotes(matches))
+		return nil
+	},
+}
+
+var rulesRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Apply the rules",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		database, matches, err := loadRuleMatches()
+		if err != nil {
+			return err
+		}
+		defer database.Close()
+
+		if len(matches) == 0 {
+			statusf("No notes match any rule\n")
+			if structuredOutput() {
+				return printOutput([]ruleMatchResult{})
+			}
+			return nil
+		}
+
+		if !structuredOutput() {
+			printRuleMatches(matches)
+			fmt.Println()
+		}
+		if !rulesYes && !confirm(fmt.Sprintf("Apply %d actions to %d notes? (y/N): ", countRuleActions(matches), countRuleNotes(matches))) {
+			statusf("Rules run cancelled\n")
+			return nil
+		}
+
+		var results []ruleMatchResult
+		failed := 0
+		for _, match := range matches {
+			result := newRuleMatchResult(match)
+			result.Status = "ok"
+			if err := applyRuleActions(cmd.Context(), database, match); err != nil {
+				result.Status = "failed"
+				result.Error = err.Error()
+				failed++
+				statusf("  ✗ [%s] %s %s: %v\n", match.rule, match.note.ID, match.note.Title, err)
+			} else {
+				statusf("  ✓ [%s] %s %s\n", match.rule, match.note.ID, match.note.Title)
+			}
+			results = append(results, result)
+		}
+
+		if structuredOutput() {
+			if err := printOutput(results); err != nil {
+				return err
+			}
+		} else if dryRun {
+			fmt.Printf("[dry-run] Would apply %d actions to %d notes\n", countRuleActions(matches), countRuleNotes(matches))
+		} else {
+			fmt.Printf("\n%d succeeded, %d failed\n", len(matches)-failed, failed)
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("rules failed for %d of %d notes", failed, len(matches))
+		}
+		return nil
+	},
+}
+
+// ruleMatch is a note matched by a rule, with the actions to apply to it.
+// note is shared between matches of the same note, so an applied move is
+// visible to later rules.
+type ruleMatch struct {
+	rule    string
+	note    *db.Note
+	folder  string // folder the note is expected to be in when the rule applies
+	actions []rules.Action
+}
+
+// ruleMatchResult is the JSON form of a rule match
+type ruleMatchResult struct {
+	Rule    string   `json:"rule"`
+	ID      string   `json:"id"`
+	Title   string   `json:"title"`
+	Folder  string   `json:"folder"`
+	Actions []string `json:"actions"`
+	Status  string   `json:"status,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+func newRuleMatchResult(match ruleMatch) ruleMatchResult {
+	result := ruleMatchResult{Rule: match.rule, ID: match.note.ID, Title: match.note.Title, Folder: match.folder}
+	for _, action := range match.actions {
+		result.Actions = append(result.Actions, action.String())
+	}
+	return result
+}
+
+func ruleMatchResults(matches []ruleMatch) []ruleMatchResult {
+	results := []ruleMatchResult{}
+	for _, match := range matches {
+		results = append(results, newRuleMatchResult(match))
+	}
+	return results
+}
+
+// loadRuleMatches loads the rules file and evaluates it against all notes
+func loadRuleMatches() (*db.DB, []ruleMatch, error) {
+	path := rulesFile
+	if path == "" {
+		var err error
+		path, err = rules.DefaultPath()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	ruleSet, err := rules.Load(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	database, err := db.Open()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	notes, err := database.ListNoteBodies("")
+	if err != nil {
+		database.Close()
+		return nil, nil, fmt.Errorf("failed to list notes: %w", err)
+	}
+	attrs, err := database.ListNoteAttributes()
+	if err != nil {
+		database.Close()
+		return nil, nil, err
+	}
+
+	// Folder conditions and move targets are compared by folder ID, since
+	// folder names repeat across paths
+	if err := rules.ResolveFolders(ruleSet, database); err != nil {
+		database.Close()
+		return nil, nil, err
+	}
+	moveTargets := make(map[string]int64)
+	for _, rule := range ruleSet {
+		for _, action := range rule.Actions {
+			if action.Type != rules.ActionMove {
+				continue
+			}
+			if moveTargets[action.Folder], err = database.FindFolder(action.Folder); err != nil {
+				database.Close()
+				return nil, nil, err
+			}
+		}
+	}
+
+	return database, evaluateRules(ruleSet, notes, attrs, moveTargets, time.Now()), nil
+}
+
+// evaluateRules matches every rule against every note in order. The expected
+// effect of each match is applied to the candidates, so later rules see moved
+// and tagged notes and skip deleted ones. moveTargets holds the folder ID of
+// each move action's folder.
+func evaluateRules(ruleSet []rules.Rule, notes []db.Note, attrs map[string]db.NoteAttributes, moveTargets map[string]int64, now time.Time) []ruleMatch {
+	candidates := make([]query.Candidate, len(notes))
+	for i, note := range notes {
+		candidates[i] = query.NewCandidate(note, attrs[note.ID])
+	}
+	shared := make([]*db.Note, len(notes))
+	for i := range notes {
+		shared[i] = &notes[i]
+	}
+	deleted := make([]bool, len(notes))
+
+	var matches []ruleMatch
+	for i := range ruleSet {
+		rule := &ruleSet[i]
+		name := rule.Name
+		if name == "" {
+			name = fmt.Sprintf("rule %d", i+1)
+		}
+
+		for j := range candidates {
+			if deleted[j] || !rule.Matches(candidates[j], now) {
+				continue
+			}
+			matches = append(matches, ruleMatch{rule: name, note: shared[j], folder: candidates[j].Note.Folder, actions: rule.Actions})
+
+			for _, action := range rule.Actions {
+				switch action.Type {
+				case rules.ActionMove:
+					candidates[j].Note.Folder = folderName(action.Folder)
+					candidates[j].Attributes.FolderID = moveTargets[action.Folder]
+				case rules.ActionTag:
+					candidates[j].Tags = append(candidates[j].Tags, action.Tag)
+				case rules.ActionDelete:
+					deleted[j] = true
+				}
+			}
+		}
+	}
+
+	return matches
+}
+
+// applyRuleActions applies the actions of a match in order, stopping at the
+// first failure
+func applyRuleActions(ctx context.Context, database *db.DB, match ruleMatch) error {
+	command := "rules: " + match.rule
+	for _, action := range match.actions {
+		var err error
+		switch action.Type {
+		case rules.ActionMove:
+			err = moveNoteTo(ctx, database, command, match.note, action.Folder)
+			if err == nil {
+				match.note.Folder = folderName(action.Folder)
+			}
+		case rules.ActionTag:
+			err = tagNote(ctx, database, command, match.note, action.Tag)
+		case rules.ActionAppend:
+			err = appendToNote(ctx, database, command, match.note, action.Text)
+		case rules.ActionDelete:
+			err = removeNote(ctx, database, command, match.note)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", action, err)
+		}
+	}
+	return nil
+}
+
+// printRuleMatches prints the planned actions per rule and note
+func printRuleMatches(matches []ruleMatch) {
+	w := tabwriter.NewWriter(previewOutput(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RULE\tID\tTITLE\tFOLDER\tACTIONS")
+	for _, match := range matches {
+		result := newRuleMatchResult(match)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			result.Rule,
+			result.ID,
+			result.Title,
+			result.Folder,
+			strings.Join(result.Actions, ", "),
+		)
+	}
+	w.Flush()
+}
+
+func countRuleActions(matches []ruleMatch) int {
+	n := 0
+	for _, match := range matches {
+		n += len(match.actions)
+	}
+	return n
+}
+
+func countRuleNotes(matches []ruleMatch) int {
+	seen := make(map[string]bool)
+	for _, match := range matches {
+		seen[match.note.ID] = true
+	}
+	return len(seen)
+}
+
+func init() {
+	rulesCmd.PersistentFlags().StringVar(&rulesFile, "file", "", "Rules file (default: ~/.config/apple-notes/rules.json)")
+	rulesRunCmd.Flags().BoolVarP(&rulesYes, "yes", "y", false, "Skip confirmation prompt")
+	rulesCmd.AddCommand(rulesCheckCmd)
+	rulesCmd.AddCommand(rulesRunCmd)
+}
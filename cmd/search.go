@@ -2,20 +2,65 @@ package cmd
 
 import (
 	"fmt"
-	"os"
-	"text/tabwriter"
+	"strings"
+	"time"
 
+	"github.com/fatih/color"
 	"github.com/fishfisher/apple-notes/internal/db"
+	"github.com/fishfisher/apple-notes/internal/index"
+	"github.com/fishfisher/apple-notes/internal/query"
 	"github.com/spf13/cobra"
 )
 
+var (
+	searchIDs    bool
+	searchRanked bool
+
+	// strictMatching turns off case and accent folding (--strict)
+	strictMatching bool
+)
+
 var searchCmd = &cobra.Command{
-	Use:   "search [term]",
+	Use:   "search [query]",
 	Short: "Search notes by title or content",
-	Long:  `Search for notes containing the specified term in their title or snippet.`,
-	Args:  cobra.ExactArgs(1),
+	Long: `Search for notes matching a query. Words and "quoted phrases" match the
+title or body; all terms must match unless combined with OR.
+
+Fields:
+  folder:Work              notes in a folder
+  tag:#incident            notes with a hashtag
+  title:standup            text in the title
+  created:>2025-01-01      created after a day (>, >=, <, <=, or = for the day)
+  modified:<30d            modified less than 30 days ago (d, w, mo, y)
+  size:>1000               body longer than 1000 characters
+  has:attachment, has:link
+  is:pinned, is:locked
+
+Combine terms with AND (implied), OR, NOT or a leading -, and group them
+with parentheses:
+
+  apple-notes search 'folder:Work (tag:#incident OR "outage") -draft'
+
+Text, titles, folders and tags match ignoring case and accents: "cafe"
+finds "Café" and "møte" finds "MØTE". Letters such as ø and æ are not
+accented forms and only match themselves. Use --strict to match text as
+written, ignoring only case.
+
+With --ranked, the full-text index built by 'apple-notes index build' is
+searched instead and results are ranked by relevance (BM25) with the
+matching words highlighted. Ranked search takes words, "phrases", OR, NOT
+and prefix* terms, but no fields.`,
+	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		searchTerm := args[0]
+		searchTerm := strings.Join(args, " ")
+		if searchRanked {
+			return runRankedSearch(searchTerm)
+		}
+
+		q, err := parseQuery(searchTerm)
+		if err != nil {
+			return err
+		}
 
 		database, err := db.Open()
 		if err != nil {
@@ -23,34 +68,187 @@ var searchCmd = &cobra.Command{
 		}
 		defer database.Close()
 
-		notes, err := database.SearchNotes(searchTerm)
+		page, err := currentPage()
+		if err != nil {
+			return err
+		}
+
+		notes, total, err := findNotesPage(database, q, page)
 		if err != nil {
 			return fmt.Errorf("failed to search notes: %w", err)
 		}
 
+		if searchIDs {
+			printNoteIDs(notes)
+			return nil
+		}
+
+		if formatTemplate != "" {
+			return printFormatted(notes)
+		}
+
+		if structuredOutput() {
+			return printOutput(noteRecords(notes))
+		}
+
 		if len(notes) == 0 {
 			fmt.Printf("No notes found matching '%s'\n", searchTerm)
 			return nil
 		}
 
-		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		fmt.Fprintln(w, "ID\tTITLE\tFOLDER\tMODIFIED\tSNIPPET")
-		for _, note := range notes {
-			snippet := note.Snippet
-			if len(snippet) > 60 {
-				snippet = snippet[:60] + "..."
-			}
-			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
-				note.ID,
-				note.Title,
-				note.Folder,
-				note.Modified.Format("2006-01-02 15:04"),
-				snippet,
-			)
-		}
-		w.Flush()
-
-		fmt.Printf("\nFound %d notes matching '%s'\n", len(notes), searchTerm)
+		if err := printNoteTable(database, notes, defaultNoteColumns); err != nil {
+			return err
+		}
+
+		if summary := pageSummary(page, len(notes), total); summary != "" {
+			fmt.Printf("\n%s matching '%s'\n", summary, searchTerm)
+		} else {
+			fmt.Printf("\nFound %d notes matching '%s'\n", len(notes), searchTerm)
+		}
 		return nil
 	},
 }
+
+// addStrictFlag registers --strict on cmd
+func addStrictFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&strictMatching, "strict", false, "Do not ignore accents or normalize Unicode when matching")
+}
+
+// parseQuery parses a search query, honoring --strict
+func parseQuery(s string) (*query.Query, error) {
+	if strictMatching {
+		return query.ParseStrict(s, time.Now())
+	}
+	return query.Parse(s, time.Now())
+}
+
+// findNotesPage returns one page of the notes matching q and the number of
+// matches. Queries that compile fully to SQL are paged by the database;
+// the rest are evaluated over decoded bodies and paged here.
+func findNotesPage(database *db.DB, q *query.Query, page db.Page) ([]db.Note, int, error) {
+	if condition, args, exact := q.SQL(); exact {
+		return database.FindNotesPage(condition, args, page)
+	}
+
+	notes, err := q.Find(database)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := sortNotes(database, notes, page.Sort, page.Reverse); err != nil {
+		return nil, 0, err
+	}
+
+	total := len(notes)
+	start := min(page.Offset, total)
+	end := total
+	if page.Limit > 0 {
+		end = min(start+page.Limit, total)
+	}
+	return notes[start:end], total, nil
+}
+
+// runRankedSearch searches the full-text index and prints hits best first
+func runRankedSearch(text string) error {
+	if tableSort != "" {
+		return fmt.Errorf("--sort cannot be combined with --ranked")
+	}
+	if strictMatching {
+		return fmt.Errorf("--strict cannot be combined with --ranked")
+	}
+	page, err := currentPage()
+	if err != nil {
+		return err
+	}
+
+	path, err := index.Path()
+	if err != nil {
+		return err
+	}
+	ix, err := index.OpenExisting(path)
+	if err != nil {
+		return err
+	}
+	defer ix.Close()
+
+	database, err := db.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer database.Close()
+
+	// Catch up with notes changed since the last update, so deleted notes
+	// do not count towards the total or shift pages
+	stale, err := ix.Stale(database)
+	if err != nil {
+		return err
+	}
+	if stale > 0 {
+		statusf("Updating the search index (%d notes changed)...\n", stale)
+		if _, err := ix.Update(database); err != nil {
+			return err
+		}
+	}
+
+	open, close := "**", "**"
+	if !structuredOutput() && !color.NoColor {
+		open, close = "\x1b[1;33m", "\x1b[0m"
+	}
+	hits, total, err := ix.Search(text, page.Limit, page.Offset, open, close)
+	if err != nil {
+		return err
+	}
+
+	// Notes deleted while searching are skipped
+	var notes []db.Note
+	var records []searchHitRecord
+	for _, hit := range hits {
+		note, err := database.GetNote(hit.ID)
+		if err != nil {
+			continue
+		}
+		notes = append(notes, *note)
+		records = append(records, newSearchHitRecord(*note, hit))
+	}
+
+	if searchIDs {
+		printNoteIDs(notes)
+		return nil
+	}
+	if formatTemplate != "" {
+		return printFormatted(notes)
+	}
+	if structuredOutput() {
+		if records == nil {
+			records = []searchHitRecord{}
+		}
+		return printOutput(records)
+	}
+
+	if len(records) == 0 {
+		fmt.Printf("No notes found matching '%s'\n", text)
+		return nil
+	}
+
+	for _, record := range records {
+		fmt.Printf("%s  %s  (%s)\n", record.ID, record.Title, record.Folder)
+		if record.Snippet != "" {
+			fmt.Printf("    %s\n", record.Snippet)
+		}
+	}
+
+	if summary := pageSummary(page, len(hits), total); summary != "" {
+		fmt.Printf("\n%s matching '%s'\n", summary, text)
+	} else {
+		fmt.Printf("\nFound %d notes matching '%s'\n", total, text)
+	}
+	return nil
+}
+
+func init() {
+	searchCmd.Flags().BoolVar(&searchIDs, "ids", false, "Print only note IDs, one per line")
+	addStrictFlag(searchCmd)
+	searchCmd.Flags().BoolVar(&searchRanked, "ranked", false, "Rank results with the full-text index (see 'apple-notes index')")
+	addPageFlags(searchCmd)
+	addTableFlags(searchCmd, defaultNoteColumns)
+	addFormatFlag(searchCmd, "ID, Title, Folder, Snippet, Created, Modified")
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fishfisher/apple-notes/internal/db"
+	"github.com/fishfisher/apple-notes/internal/query"
+	"github.com/spf13/cobra"
+)
+
+// noteSelection selects notes for bulk operations. All given criteria must
+// match. Without any criteria, note IDs are read from stdin when it is piped.
+type noteSelection struct {
+	query   string
+	tag     string
+	folder  string
+	idsFrom string
+}
+
+// addFlags registers the selection flags on cmd
+func (s *noteSelection) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&s.query, "query", "q", "", "Select notes matching a search query (see search --help)")
+	cmd.Flags().StringVar(&s.tag, "tag", "", "Select notes with this hashtag anywhere in the body")
+	cmd.Flags().StringVarP(&s.folder, "folder", "f", "", "Select notes in this folder (a path such as Work/Clients)")
+	cmd.Flags().StringVar(&s.idsFrom, "ids-from", "", "Read note IDs from a file (one per line, - for stdin)")
+}
+
+// empty reports whether no selection criteria were given
+func (s *noteSelection) empty() bool {
+	return s.query == "" && s.tag == "" && s.folder == "" && s.idsFrom == ""
+}
+
+// resolve returns the selected notes in a stable order
+func (s *noteSelection) resolve(database *db.DB) ([]db.Note, error) {
+	idsFrom := s.idsFrom
+	if s.empty() {
+		if stdinIsTerminal() {
+			return nil, fmt.Errorf("no notes selected: use --query, --tag, --folder, --ids-from or pipe note IDs on stdin")
+		}
+		idsFrom = "-"
+	}
+
+	var sets [][]db.Note
+	if idsFrom != "" {
+		ids, err := readNoteIDs(idsFrom)
+		if err != nil {
+			return nil, err
+		}
+		var notes []db.Note
+		for _, id := range ids {
+			note, err := database.GetNote(id)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: skipping %s: %v\n", id, err)
+				continue
+			}
+			notes = append(notes, *note)
+		}
+		sets = append(sets, notes)
+	}
+	if s.query != "" {
+		q, err := query.Parse(s.query, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		notes, err := q.Find(database)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search notes: %w", err)
+		}
+		sets = append(sets, notes)
+	}
+	if s.tag != "" {
+		// Tags match like the query language's tag: term, on the whole body
+		notes, err := database.ListNoteBodies("")
+		if err != nil {
+			return nil, fmt.Errorf("failed to list notes: %w", err)
+		}
+		var tagged []db.Note
+		for _, note := range notes {
+			if query.NewCandidate(note, db.NoteAttributes{}).HasTag(s.tag) {
+				tagged = append(tagged, note)
+			}
+		}
+		sets = append(sets, tagged)
+	}
+	if s.folder != "" {
+		folderID, err := database.FindFolder(s.folder)
+		if err != nil {
+			return nil, err
+		}
+		if folderID == 0 {
+			return nil, fmt.Errorf("folder '%s' not found", s.folder)
+		}
+		notes, err := database.ListNotesInFolder(folderID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list notes: %w", err)
+		}
+		sets = append(sets, notes)
+	}
+
+	return intersectNotes(sets), nil
+}
+
+// intersectNotes keeps the notes of the first set that appear in every set,
+// dropping duplicates
+func intersectNotes(sets [][]db.Note) []db.Note {
+	if len(sets) == 0 {
+		return nil
+	}
+
+	counts := make(map[string]int)
+	for _, set := range sets {
+		seen := make(map[string]bool)
+		for _, note := range set {
+			if !seen[note.ID] {
+				seen[note.ID] = true
+				counts[note.ID]++
+			}
+		}
+	}
+
+	var result []db.Note
+	added := make(map[string]bool)
+	for _, note := range sets[0] {
+		if counts[note.ID] == len(sets) && !added[note.ID] {
+			added[note.ID] = true
+			result = append(result, note)
+		}
+	}
+	return result
+}
+
+// readNoteIDs reads note IDs from a file, or stdin for "-". The first field of
+// each line is used, so blank lines and "#" comments are skipped.
+func readNoteIDs(path string) ([]string, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open ID file: %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var ids []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		ids = append(ids, fields[0])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read note IDs: %w", err)
+	}
+	return ids, nil
+}
@@ -11,7 +11,7 @@ import (
 var showCmd = &cobra.Command{
 	Use:   "show [note-id]",
 	Short: "Show a specific note",
-	Long:  `Display the full content of a specific note by ID.`,
+	Long:  `Display the full content of a specific note by ID or ~title.`,
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		noteID := args[0]
@@ -22,15 +22,26 @@ var showCmd = &cobra.Command{
 		}
 		defer database.Close()
 
-		note, err := database.GetNote(noteID)
+		note, err := resolveNote(database, noteID)
 		if err != nil {
 			return err
 		}
 
-		// Check for duplicate titles
-		count, err := database.CountNotesByTitle(note.Title)
-		if err == nil && count > 1 {
-			fmt.Printf("⚠️  Warning: Found %d notes with title '%s'. Showing most recently modified.\n\n", count, note.Title)
+		// Fetch by ID so notes sharing a title cannot be confused
+		scriptID, err := database.AppleScriptID(note.ID)
+		if err != nil {
+			return err
+		}
+
+		if structuredOutput() {
+			record := newNoteRecord(*note)
+			record.Body = note.Snippet
+			if body, err := applescript.GetNoteBodyByID(cmd.Context(), scriptID); err == nil {
+				record.Body = body
+			} else {
+				statusf("Warning: Could not retrieve full note body, showing snippet only: %v\n", err)
+			}
+			return printOutput(record)
 		}
 
 		fmt.Printf("ID:       %s\n", note.ID)
@@ -41,7 +52,7 @@ var showCmd = &cobra.Command{
 		fmt.Printf("\n")
 
 		// Get full body from AppleScript instead of just snippet
-		body, err := applescript.GetNoteBody(note.Title)
+		body, err := applescript.GetNoteBodyByID(cmd.Context(), scriptID)
 		if err != nil {
 			// Fallback to snippet if AppleScript fails
 			fmt.Printf("Warning: Could not retrieve full note body, showing snippet only: %v\n\n", err)
@@ -40,13 +40,13 @@ func SetSkillFS(fsys fs.FS, dirName, name string) {
 	skillName = name
 }
 
-// choose prints a numbered menu and returns the 0-based index, or -1 on invalid input.
+// choose prints a numbered menu to stderr and returns the 0-based index, or -1 on invalid input.
 func choose(prompt string, options []string) int {
-	fmt.Println(prompt)
+	fmt.Fprintln(os.Stderr, prompt)
 	for i, opt := range options {
-		fmt.Printf("  %d) %s\n", i+1, opt)
+		fmt.Fprintf(os.Stderr, "  %d) %s\n", i+1, opt)
 	}
-	fmt.Print("> ")
+	fmt.Fprint(os.Stderr, "> ")
 	reader := bufio.NewReader(os.Stdin)
 	line, _ := reader.ReadString('\n')
 	line = strings.TrimSpace(line)
@@ -23,7 +23,11 @@ var statsCmd = &cobra.Command{
 			return fmt.Errorf("failed to get stats: %w", err)
 		}
 
-		fmt.Println("=== Apple Notes Statistics ===\n")
+		if structuredOutput() {
+			return printOutput(newStatsRecord(stats))
+		}
+
+		fmt.Print("=== Apple Notes Statistics ===\n\n")
 		fmt.Printf("Total notes:           %d\n", stats.TotalNotes)
 		fmt.Printf("Total folders:         %d\n", stats.TotalFolders)
 		fmt.Printf("Modified this week:    %d\n", stats.NotesThisWeek)
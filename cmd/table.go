@@ -0,0 +1,257 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/fishfisher/apple-notes/internal/db"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// tableColumn describes one column of a table. Flexible columns are
+// truncated, in order, when the table is wider than the terminal.
+type tableColumn struct {
+	Header   string
+	MaxWidth int
+	Flexible bool
+}
+
+// table renders rows as aligned columns
+type table struct {
+	columns []tableColumn
+	rows    [][]string
+	indent  string
+}
+
+// minFlexibleWidth is the narrowest a flexible column is shrunk to
+const minFlexibleWidth = 10
+
+func (t *table) addRow(cells ...string) {
+	t.rows = append(t.rows, cells)
+}
+
+// render writes the table to w. If width is positive, flexible columns are
+// shrunk so that lines fit in width characters.
+func (t *table) render(w io.Writer, width int) {
+	widths := make([]int, len(t.columns))
+	for i, column := range t.columns {
+		widths[i] = utf8.RuneCountInString(column.Header)
+		for _, row := range t.rows {
+			widths[i] = max(widths[i], utf8.RuneCountInString(row[i]))
+		}
+		if column.MaxWidth > 0 {
+			widths[i] = min(widths[i], column.MaxWidth)
+		}
+	}
+
+	if width > 0 {
+		total := utf8.RuneCountInString(t.indent) + 2*(len(widths)-1)
+		for _, w := range widths {
+			total += w
+		}
+		for i, column := range t.columns {
+			if total <= width {
+				break
+			}
+			if !column.Flexible || widths[i] <= minFlexibleWidth {
+				continue
+			}
+			shrink := min(total-width, widths[i]-minFlexibleWidth)
+			widths[i] -= shrink
+			total -= shrink
+		}
+	}
+
+	headers := make([]string, len(t.columns))
+	for i, column := range t.columns {
+		headers[i] = column.Header
+	}
+	t.renderRow(w, headers, widths)
+	for _, row := range t.rows {
+		t.renderRow(w, row, widths)
+	}
+}
+
+func (t *table) renderRow(w io.Writer, cells []string, widths []int) {
+	var line strings.Builder
+	line.WriteString(t.indent)
+	for i, cell := range cells {
+		cell = truncateRunes(widths[i], cell)
+		line.WriteString(cell)
+		if i < len(cells)-1 {
+			line.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)+2))
+		}
+	}
+	fmt.Fprintln(w, strings.TrimRight(line.String(), " "))
+}
+
+// terminalWidth returns the width of the terminal on stdout, or 0 when
+// stdout is not a terminal
+func terminalWidth() int {
+	fd := int(os.Stdout.Fd())
+	if !term.IsTerminal(fd) {
+		return 0
+	}
+	width, _, err := term.GetSize(fd)
+	if err != nil {
+		return 0
+	}
+	return width
+}
+
+// Set by the table flags on commands that print notes
+var (
+	tableColumns []string
+	tableSort    string
+	tableReverse bool
+)
+
+// Default columns for commands that print notes
+var (
+	defaultNoteColumns = []string{"id", "title", "folder", "modified", "snippet"}
+	shortNoteColumns   = []string{"id", "title", "folder", "modified"}
+)
+
+// noteColumn is a column that can be selected with --columns
+type noteColumn struct {
+	tableColumn
+	value func(note db.Note, size int64) string
+}
+
+var noteColumns = map[string]noteColumn{
+	"id": {tableColumn{Header: "ID"}, func(note db.Note, _ int64) string {
+		return note.ID
+	}},
+	"title": {tableColumn{Header: "TITLE", Flexible: true}, func(note db.Note, _ int64) string {
+		return note.Title
+	}},
+	"folder": {tableColumn{Header: "FOLDER"}, func(note db.Note, _ int64) string {
+		return note.Folder
+	}},
+	"created": {tableColumn{Header: "CREATED"}, func(note db.Note, _ int64) string {
+		return note.Created.Format("2006-01-02 15:04")
+	}},
+	"modified": {tableColumn{Header: "MODIFIED"}, func(note db.Note, _ int64) string {
+		return note.Modified.Format("2006-01-02 15:04")
+	}},
+	"snippet": {tableColumn{Header: "SNIPPET", MaxWidth: 63, Flexible: true}, func(note db.Note, _ int64) string {
+		return note.Snippet
+	}},
+	"size": {tableColumn{Header: "SIZE"}, func(_ db.Note, size int64) string {
+		return formatSize(size)
+	}},
+}
+
+// noteColumnNames lists the values accepted by --columns
+var noteColumnNames = []string{"id", "title", "folder", "created", "modified", "snippet", "size"}
+
+// addTableFlags registers --columns, --sort and --reverse on a command that
+// prints notes
+func addTableFlags(cmd *cobra.Command, defaults []string) {
+	cmd.Flags().StringSliceVar(&tableColumns, "columns", nil, fmt.Sprintf("Columns to show: %s (default %s)", strings.Join(noteColumnNames, ", "), strings.Join(defaults, ",")))
+	cmd.Flags().StringVar(&tableSort, "sort", "", "Sort by "+strings.Join(db.SortKeys, ", "))
+	cmd.Flags().BoolVar(&tableReverse, "reverse", false, "Reverse the sort order")
+}
+
+// sortNotes orders notes by one of db.SortKeys, like the database does for
+// paged queries. Titles and folders sort A-Z, dates newest first and sizes
+// largest first.
+func sortNotes(database *db.DB, notes []db.Note, key string, reverse bool) error {
+	var sizes map[string]int64
+	var less func(a, b db.Note) bool
+	switch key {
+	case "":
+	case "title":
+		less = func(a, b db.Note) bool { return strings.ToLower(a.Title) < strings.ToLower(b.Title) }
+	case "folder":
+		less = func(a, b db.Note) bool { return strings.ToLower(a.Folder) < strings.ToLower(b.Folder) }
+	case "created":
+		less = func(a, b db.Note) bool { return a.Created.After(b.Created) }
+	case "modified":
+		less = func(a, b db.Note) bool { return a.Modified.After(b.Modified) }
+	case "size":
+		var err error
+		if sizes, err = database.ListNoteSizes(); err != nil {
+			return err
+		}
+		less = func(a, b db.Note) bool { return sizes[a.ID] > sizes[b.ID] }
+	default:
+		return fmt.Errorf("invalid sort %q (use %s)", key, strings.Join(db.SortKeys, ", "))
+	}
+
+	if less != nil {
+		sort.SliceStable(notes, func(i, j int) bool { return less(notes[i], notes[j]) })
+	}
+	if reverse {
+		for i, j := 0, len(notes)-1; i < j; i, j = i+1, j-1 {
+			notes[i], notes[j] = notes[j], notes[i]
+		}
+	}
+	return nil
+}
+
+// printNoteTable prints notes with the columns from --columns, or defaults
+// when it is not set. Lines are fitted to the terminal width.
+func printNoteTable(database *db.DB, notes []db.Note, defaults []string) error {
+	names := tableColumns
+	if len(names) == 0 {
+		names = defaults
+	}
+
+	t := &table{}
+	var selected []noteColumn
+	var sizes map[string]int64
+	for _, name := range names {
+		key := strings.ToLower(strings.TrimSpace(name))
+		column, ok := noteColumns[key]
+		if !ok {
+			return fmt.Errorf("unknown column %q (use %s)", name, strings.Join(noteColumnNames, ", "))
+		}
+		if key == "size" && sizes == nil {
+			var err error
+			if sizes, err = database.ListNoteSizes(); err != nil {
+				return err
+			}
+		}
+		selected = append(selected, column)
+		t.columns = append(t.columns, column.tableColumn)
+	}
+
+	for _, note := range notes {
+		cells := make([]string, len(selected))
+		for i, column := range selected {
+			cells[i] = column.value(note, sizes[note.ID])
+		}
+		t.addRow(cells...)
+	}
+
+	t.render(os.Stdout, terminalWidth())
+	return nil
+}
+
+// withoutColumn returns columns without name
+func withoutColumn(columns []string, name string) []string {
+	var result []string
+	for _, column := range columns {
+		if column != name {
+			result = append(result, column)
+		}
+	}
+	return result
+}
+
+// formatSize formats a byte count for display
+func formatSize(size int64) string {
+	switch {
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", size)
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/fishfisher/apple-notes/internal/applescript"
 	"github.com/fishfisher/apple-notes/internal/db"
+	"github.com/fishfisher/apple-notes/internal/journal"
 	"github.com/spf13/cobra"
 )
 
@@ -25,12 +26,21 @@ var tagsListCmd = &cobra.Command{
 			return fmt.Errorf("failed to open database: %w", err)
 		}
 		defer database.Close()
+		database.SetStrict(strictMatching)
 
 		tags, err := database.ExtractTags()
 		if err != nil {
 			return fmt.Errorf("failed to extract tags: %w", err)
 		}
 
+		if formatTemplate != "" {
+			return printFormatted(tags)
+		}
+
+		if structuredOutput() {
+			return printOutput(tagRecords(tags))
+		}
+
 		if len(tags) == 0 {
 			fmt.Println("No tags found")
 			return nil
@@ -60,30 +70,40 @@ var tagsSearchCmd = &cobra.Command{
 			return fmt.Errorf("failed to open database: %w", err)
 		}
 		defer database.Close()
+		database.SetStrict(strictMatching)
+
+		page, err := currentPage()
+		if err != nil {
+			return err
+		}
 
-		notes, err := database.SearchByTag(tag)
+		notes, total, err := database.SearchByTagPage(tag, page)
 		if err != nil {
 			return fmt.Errorf("failed to search by tag: %w", err)
 		}
 
+		if formatTemplate != "" {
+			return printFormatted(notes)
+		}
+
+		if structuredOutput() {
+			return printOutput(noteRecords(notes))
+		}
+
 		if len(notes) == 0 {
 			fmt.Printf("No notes found with tag '%s'\n", tag)
 			return nil
 		}
 
-		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		fmt.Fprintln(w, "ID\tTITLE\tFOLDER\tMODIFIED")
-		for _, note := range notes {
-			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
-				note.ID,
-				note.Title,
-				note.Folder,
-				note.Modified.Format("2006-01-02 15:04"),
-			)
+		if err := printNoteTable(database, notes, shortNoteColumns); err != nil {
+			return err
 		}
-		w.Flush()
 
-		fmt.Printf("\nFound %d notes with tag '%s'\n", len(notes), tag)
+		if summary := pageSummary(page, len(notes), total); summary != "" {
+			fmt.Printf("\n%s with tag '%s'\n", summary, tag)
+		} else {
+			fmt.Printf("\nFound %d notes with tag '%s'\n", len(notes), tag)
+		}
 		return nil
 	},
 }
@@ -104,18 +124,39 @@ var tagsAddCmd = &cobra.Command{
 		}
 		defer database.Close()
 
-		note, err := database.GetNote(noteID)
+		note, err := resolveNoteForWrite(database, noteID)
 		if err != nil {
-			return fmt.Errorf("note not found: %w", err)
+			return err
 		}
 
-		fmt.Printf("Adding tag '%s' to note '%s'...\n", tag, note.Title)
-		if err := applescript.AddTagToNote(note.Title, tag); err != nil {
+		before, err := database.ModificationStamp(note.ID)
+		if err != nil {
+			return err
+		}
+
+		scriptID, err := database.AppleScriptID(note.ID)
+		if err != nil {
+			return err
+		}
+
+		entry, err := snapshotNote(cmd.Context(), database, "tags add", journal.ActionUpdate, note)
+		if err != nil {
+			return err
+		}
+
+		planWrite("tag", *note)
+		statusf("Adding tag '%s' to note '%s'...\n", tag, note.Title)
+		if err := applescript.AddTagToNoteByID(cmd.Context(), scriptID, tag); err != nil {
 			return fmt.Errorf("failed to add tag: %w", err)
 		}
+		recordJournal(entry)
 
-		fmt.Println("Tag added successfully")
-		return nil
+		note, err = verifyModified(cmd.Context(), database, note.ID, before)
+		if err != nil {
+			return err
+		}
+
+		return reportWrite(noteResult("tagged", note), "Tag added successfully")
 	},
 }
 
@@ -123,4 +164,11 @@ func init() {
 	tagsCmd.AddCommand(tagsListCmd)
 	tagsCmd.AddCommand(tagsSearchCmd)
 	tagsCmd.AddCommand(tagsAddCmd)
+
+	addFormatFlag(tagsListCmd, "Name, Count")
+	addStrictFlag(tagsListCmd)
+	addStrictFlag(tagsSearchCmd)
+	addPageFlags(tagsSearchCmd)
+	addTableFlags(tagsSearchCmd, shortNoteColumns)
+	addFormatFlag(tagsSearchCmd, "ID, Title, Folder, Snippet, Created, Modified")
 }
@@ -1,24 +1,29 @@
 package cmd
 
 import (
+	"bufio"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
-	"path/filepath"
+	"os/exec"
+	"strings"
+	"time"
 
 	"github.com/fishfisher/apple-notes/internal/applescript"
+	"github.com/fishfisher/apple-notes/internal/db"
+	"github.com/fishfisher/apple-notes/internal/templates"
 	"github.com/spf13/cobra"
 )
 
-type Template struct {
-	Name string `json:"name"`
-	Body string `json:"body"`
-}
-
 var templateCmd = &cobra.Command{
 	Use:   "template",
 	Short: "Manage note templates",
-	Long:  `Create, list, and use note templates.`,
+	Long: `Create, list, and use note templates.
+
+Templates are stored one per file in ~/.config/apple-notes/templates/<name>.tmpl
+(or $XDG_CONFIG_HOME/apple-notes/templates). Templates from the old
+~/.apple-notes-templates.json file are moved there automatically.`,
 }
 
 var templateCreateCmd = &cobra.Command{
@@ -33,12 +38,15 @@ var templateCreateCmd = &cobra.Command{
 			return fmt.Errorf("--body flag is required")
 		}
 
-		template := Template{
+		template := templates.Template{
 			Name: templateName,
 			Body: body,
 		}
 
-		if err := saveTemplate(template); err != nil {
+		if _, err := templates.Variables(template.Body); err != nil {
+			return err
+		}
+		if err := templates.Save(template); err != nil {
 			return fmt.Errorf("failed to save template: %w", err)
 		}
 
@@ -51,18 +59,18 @@ var templateListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all templates",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		templates, err := loadTemplates()
+		saved, err := templates.List()
 		if err != nil {
 			return fmt.Errorf("failed to load templates: %w", err)
 		}
 
-		if len(templates) == 0 {
+		if len(saved) == 0 {
 			fmt.Println("No templates found")
 			return nil
 		}
 
 		fmt.Println("Available templates:")
-		for _, tmpl := range templates {
+		for _, tmpl := range saved {
 			fmt.Printf("  - %s\n", tmpl.Name)
 		}
 
@@ -70,104 +78,418 @@ var templateListCmd = &cobra.Command{
 	},
 }
 
-var templateUseCmd = &cobra.Command{
-	Use:   "use [template-name] [note-title]",
-	Short: "Create a note from a template",
-	Args:  cobra.ExactArgs(2),
+var templateShowCmd = &cobra.Command{
+	Use:   "show [name]",
+	Short: "Show a template",
+	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		templateName := args[0]
-		noteTitle := args[1]
-		folder, _ := cmd.Flags().GetString("folder")
+		template, err := templates.Get(args[0])
+		if err != nil {
+			return err
+		}
+
+		if structuredOutput() {
+			return printOutput(template)
+		}
+
+		fmt.Print(template.Body)
+		if !strings.HasSuffix(template.Body, "\n") {
+			fmt.Println()
+		}
+
+		names, err := templates.Variables(template.Body)
+		if err == nil && len(names) > 0 {
+			fmt.Fprintf(os.Stderr, "\nVariables: %s\n", strings.Join(names, ", "))
+		}
+		return nil
+	},
+}
 
-		templates, err := loadTemplates()
+var templateEditCmd = &cobra.Command{
+	Use:   "edit [name]",
+	Short: "Edit a template in $EDITOR",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if _, err := templates.Get(name); err != nil {
+			return err
+		}
+		path, err := templates.Path(name)
 		if err != nil {
-			return fmt.Errorf("failed to load templates: %w", err)
+			return err
+		}
+
+		if err := runEditor(path); err != nil {
+			return err
+		}
+
+		template, err := templates.Get(name)
+		if err != nil {
+			return err
+		}
+		if _, err := templates.Variables(template.Body); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+
+		fmt.Printf("Template '%s' saved\n", name)
+		return nil
+	},
+}
+
+var templateDeleteCmd = &cobra.Command{
+	Use:   "delete [name]",
+	Short: "Delete a template",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		force, _ := cmd.Flags().GetBool("force")
+
+		if _, err := templates.Get(name); err != nil {
+			return err
+		}
+		if !force && !confirm(fmt.Sprintf("Delete template '%s'? (y/N): ", name)) {
+			statusf("Deletion cancelled\n")
+			return nil
+		}
+		if dryRun {
+			fmt.Printf("[dry-run] Would delete template '%s'\n", name)
+			return nil
 		}
 
-		var template *Template
-		for _, tmpl := range templates {
-			if tmpl.Name == templateName {
-				template = &tmpl
-				break
+		if err := templates.Delete(name); err != nil {
+			return err
+		}
+		fmt.Printf("Template '%s' deleted successfully\n", name)
+		return nil
+	},
+}
+
+var templateRenameCmd = &cobra.Command{
+	Use:   "rename [name] [new-name]",
+	Short: "Rename a template",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if dryRun {
+			if _, err := templates.Get(args[0]); err != nil {
+				return err
 			}
+			fmt.Printf("[dry-run] Would rename template '%s' to '%s'\n", args[0], args[1])
+			return nil
 		}
 
-		if template == nil {
-			return fmt.Errorf("template '%s' not found", templateName)
+		if err := templates.Rename(args[0], args[1]); err != nil {
+			return err
 		}
+		fmt.Printf("Template '%s' renamed to '%s'\n", args[0], args[1])
+		return nil
+	},
+}
 
-		if folder == "" {
-			folder = "Notes"
+var templateFromNoteCmd = &cobra.Command{
+	Use:   "from-note [note-id]",
+	Short: "Create a template from an existing note",
+	Long: `Save the plain text of an existing note as a template. The note's title
+line is dropped, and any template delimiters in the text are escaped so the
+template reproduces it verbatim. Use --name to choose the template name
+(default: the note title).`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, _ := cmd.Flags().GetString("name")
+		force, _ := cmd.Flags().GetBool("force")
+
+		database, err := db.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
 		}
+		defer database.Close()
 
-		fmt.Printf("Creating note '%s' from template '%s'...\n", noteTitle, templateName)
-		if err := applescript.AddNote(noteTitle, template.Body, folder); err != nil {
-			return fmt.Errorf("failed to create note: %w", err)
+		note, err := resolveNote(database, args[0])
+		if err != nil {
+			return err
+		}
+		text, err := database.GetNoteText(note.ID)
+		if err != nil {
+			return fmt.Errorf("failed to read note: %w", err)
+		}
+
+		// The first line of the text is the note title
+		if first, rest, ok := strings.Cut(text, "\n"); ok && strings.TrimSpace(first) == note.Title {
+			text = rest
+		} else if !ok && strings.TrimSpace(text) == note.Title {
+			text = ""
 		}
 
-		fmt.Println("Note created successfully")
+		if name == "" {
+			name = note.Title
+		}
+		if err := templates.ValidateName(name); err != nil {
+			return fmt.Errorf("%w; choose another with --name", err)
+		}
+		exists, err := templates.Exists(name)
+		if err != nil {
+			return err
+		}
+		if exists && !force {
+			return fmt.Errorf("template '%s' already exists (use --force to overwrite)", name)
+		}
+
+		if err := templates.Save(templates.Template{Name: name, Body: templates.Escape(text)}); err != nil {
+			return fmt.Errorf("failed to save template: %w", err)
+		}
+		fmt.Printf("Template '%s' created from note '%s'\n", name, note.Title)
 		return nil
 	},
 }
 
-func getTemplatesPath() (string, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return "", err
-	}
-	return filepath.Join(home, ".apple-notes-templates.json"), nil
+var templateExportCmd = &cobra.Command{
+	Use:   "export [name...]",
+	Short: "Export templates to a JSON file",
+	Long: `Export templates to a JSON file for sharing. Without names, all templates
+are exported. Without --file, the JSON is written to stdout.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, _ := cmd.Flags().GetString("file")
+
+		var selected []templates.Template
+		if len(args) == 0 {
+			var err error
+			selected, err = templates.List()
+			if err != nil {
+				return fmt.Errorf("failed to load templates: %w", err)
+			}
+		}
+		for _, name := range args {
+			template, err := templates.Get(name)
+			if err != nil {
+				return err
+			}
+			selected = append(selected, *template)
+		}
+		if selected == nil {
+			selected = []templates.Template{}
+		}
+
+		data, err := json.MarshalIndent(selected, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode templates: %w", err)
+		}
+		data = append(data, '\n')
+
+		if file == "" || file == "-" {
+			_, err := os.Stdout.Write(data)
+			return err
+		}
+		if err := os.WriteFile(file, data, 0644); err != nil {
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+		fmt.Printf("Exported %d templates to %s\n", len(selected), file)
+		return nil
+	},
 }
 
-func loadTemplates() ([]Template, error) {
-	path, err := getTemplatesPath()
-	if err != nil {
-		return nil, err
-	}
+var templateImportCmd = &cobra.Command{
+	Use:   "import [file]",
+	Short: "Import templates from a JSON file",
+	Long: `Import templates from a JSON file written by 'template export' (or the old
+~/.apple-notes-templates.json format). Existing templates are skipped unless
+--force is given. Use - to read from stdin.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		force, _ := cmd.Flags().GetBool("force")
+
+		var data []byte
+		var err error
+		if args[0] == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(args[0])
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read file: %w", err)
+		}
+
+		var imported []templates.Template
+		if err := json.Unmarshal(data, &imported); err != nil {
+			return fmt.Errorf("failed to parse templates: %w", err)
+		}
+
+		added, skipped := 0, 0
+		for _, template := range imported {
+			if err := templates.ValidateName(template.Name); err != nil {
+				return err
+			}
+			exists, err := templates.Exists(template.Name)
+			if err != nil {
+				return err
+			}
+			if exists && !force {
+				fmt.Printf("Skipping '%s': already exists\n", template.Name)
+				skipped++
+				continue
+			}
+			if dryRun {
+				fmt.Printf("[dry-run] Would import template '%s'\n", template.Name)
+			} else if err := templates.Save(template); err != nil {
+				return fmt.Errorf("failed to save template: %w", err)
+			}
+			added++
+		}
 
-	data, err := os.ReadFile(path)
-	if os.IsNotExist(err) {
-		return []Template{}, nil
+		if dryRun {
+			return nil
+		}
+		fmt.Printf("Imported %d templates (%d skipped)\n", added, skipped)
+		return nil
+	},
+}
+
+// runEditor opens path in $VISUAL or $EDITOR, falling back to vi
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
 	}
-	if err != nil {
-		return nil, err
+	if editor == "" {
+		editor = "vi"
 	}
 
-	var templates []Template
-	if err := json.Unmarshal(data, &templates); err != nil {
-		return nil, err
+	// The editor setting may include arguments, e.g. "code --wait"
+	fields := strings.Fields(editor)
+	c := exec.Command(fields[0], append(fields[1:], path)...)
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("editor failed: %w", err)
 	}
+	return nil
+}
+
+var templateUseCmd = &cobra.Command{
+	Use:   "use [template-name] [note-title]",
+	Short: "Create a note from a template",
+	Long: `Create a note from a template. The template body and the note title are
+rendered with Go's text/template, so they can use variables and functions:
+
+  {{.project}}              variable, set with --var project=Apollo
+  {{date}}                  today's date (2006-01-02)
+  {{date "Monday 2 Jan"}}   today's date in a Go time layout
+  {{weekday}}               today's weekday
+  {{env "USER"}}            environment variable
+
+Variables that are not set with --var are prompted for.
+
+Example:
+  apple-notes template use standup --title "Standup {{date}}" --var team=core`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		templateName := args[0]
+		folder, _ := cmd.Flags().GetString("folder")
+		titleTemplate, _ := cmd.Flags().GetString("title")
+		varPairs, _ := cmd.Flags().GetStringArray("var")
+
+		if len(args) == 2 {
+			if titleTemplate != "" {
+				return fmt.Errorf("give the note title either as an argument or with --title, not both")
+			}
+			titleTemplate = args[1]
+		}
+		if titleTemplate == "" {
+			return fmt.Errorf("note title required (argument or --title)")
+		}
+
+		template, err := templates.Get(templateName)
+		if err != nil {
+			return err
+		}
+
+		vars, err := templates.ParseVars(varPairs)
+		if err != nil {
+			return err
+		}
+		noteTitle, body, err := renderTemplate(*template, titleTemplate, vars, time.Now())
+		if err != nil {
+			return err
+		}
+
+		if folder == "" {
+			folder = "Notes"
+		}
+
+		database, err := db.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		defer database.Close()
+
+		lastID, err := database.MaxObjectID()
+		if err != nil {
+			return err
+		}
+
+		statusf("Creating note '%s' from template '%s'...\n", noteTitle, templateName)
+		if err := applescript.AddNote(cmd.Context(), noteTitle, body, folder); err != nil {
+			return fmt.Errorf("failed to create note: %w", err)
+		}
+
+		note, err := verifyCreated(cmd.Context(), database, noteTitle, folder, lastID)
+		if err != nil {
+			return err
+		}
+		note = recordCreate(database, "template use", note, noteTitle, folder, lastID)
 
-	return templates, nil
+		return reportWrite(noteResult("created", note), "Note created successfully")
+	},
 }
 
-func saveTemplate(template Template) error {
-	templates, err := loadTemplates()
-	if err != nil {
-		return err
+// renderTemplate renders a note title and a template body with vars as of
+// now, prompting for variables that are still undefined
+func renderTemplate(tmpl templates.Template, titleTemplate string, vars map[string]string, now time.Time) (string, string, error) {
+	var missing []string
+	for _, text := range []string{titleTemplate, tmpl.Body} {
+		names, err := templates.Variables(text)
+		if err != nil {
+			return "", "", err
+		}
+		for _, name := range names {
+			if _, ok := vars[name]; !ok && !containsString(missing, name) {
+				missing = append(missing, name)
+			}
+		}
 	}
 
-	// Check if template already exists
-	for i, tmpl := range templates {
-		if tmpl.Name == template.Name {
-			templates[i] = template
-			goto save
+	if len(missing) > 0 {
+		if !stdinIsTerminal() {
+			return "", "", fmt.Errorf("missing template variables: %s (set them with --var key=value)", strings.Join(missing, ", "))
+		}
+		reader := bufio.NewReader(os.Stdin)
+		for _, name := range missing {
+			fmt.Printf("%s: ", name)
+			value, err := reader.ReadString('\n')
+			if err != nil && value == "" {
+				return "", "", fmt.Errorf("failed to read value for %s: %w", name, err)
+			}
+			vars[name] = strings.TrimRight(value, "\r\n")
 		}
 	}
 
-	templates = append(templates, template)
-
-save:
-	data, err := json.MarshalIndent(templates, "", "  ")
+	title, err := templates.Render("title", titleTemplate, vars, now)
 	if err != nil {
-		return err
+		return "", "", err
 	}
-
-	path, err := getTemplatesPath()
+	body, err := templates.Render(tmpl.Name, tmpl.Body, vars, now)
 	if err != nil {
-		return err
+		return "", "", err
 	}
+	return strings.TrimSpace(title), body, nil
+}
 
-	return os.WriteFile(path, data, 0644)
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
 }
 
 func init() {
@@ -175,8 +497,26 @@ func init() {
 	templateCreateCmd.MarkFlagRequired("body")
 
 	templateUseCmd.Flags().StringP("folder", "f", "Notes", "Folder to create note in")
+	templateUseCmd.Flags().String("title", "", "Note title, may use template functions and variables")
+	templateUseCmd.Flags().StringArray("var", nil, "Template variable as key=value (repeatable)")
+
+	templateDeleteCmd.Flags().Bool("force", false, "Skip confirmation prompt")
+
+	templateFromNoteCmd.Flags().String("name", "", "Template name (default: the note title)")
+	templateFromNoteCmd.Flags().Bool("force", false, "Overwrite an existing template")
+
+	templateExportCmd.Flags().String("file", "", "Output file (default: stdout)")
+
+	templateImportCmd.Flags().Bool("force", false, "Overwrite existing templates")
 
 	templateCmd.AddCommand(templateCreateCmd)
 	templateCmd.AddCommand(templateListCmd)
+	templateCmd.AddCommand(templateShowCmd)
 	templateCmd.AddCommand(templateUseCmd)
+	templateCmd.AddCommand(templateEditCmd)
+	templateCmd.AddCommand(templateDeleteCmd)
+	templateCmd.AddCommand(templateRenameCmd)
+	templateCmd.AddCommand(templateFromNoteCmd)
+	templateCmd.AddCommand(templateExportCmd)
+	templateCmd.AddCommand(templateImportCmd)
 }
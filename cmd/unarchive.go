@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fishfisher/apple-notes/internal/applescript"
+	"github.com/fishfisher/apple-notes/internal/archive"
+	"github.com/fishfisher/apple-notes/internal/db"
+	"github.com/spf13/cobra"
+)
+
+var (
+	unarchiveAll   bool
+	unarchiveSince string
+	unarchiveYes   bool
+)
+
+var unarchiveCmd = &cobra.Command{
+	Use:   "unarchive [note-id]",
+	Short: "Move archived notes back to their original folders",
+	Long: `Move notes archived with 'apple-notes archive' back to the folders they came
+from. Folders that no longer exist are recreated.
+
+Pass a note ID or ~title, or --all to restore every archived note. --since limits --all
+to notes archived on or after a date (YYYY-MM-DD).`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if (len(args) == 1) == unarchiveAll {
+			return fmt.Errorf("specify a note ID or --all")
+		}
+		if unarchiveSince != "" && !unarchiveAll {
+			return fmt.Errorf("--since can only be used with --all")
+		}
+
+		records, err := archive.Load()
+		if err != nil {
+			return err
+		}
+
+		database, err := db.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		defer database.Close()
+
+		var selected []archive.Record
+		if unarchiveAll {
+			var since time.Time
+			if unarchiveSince != "" {
+				since, err = time.ParseInLocation("2006-01-02", unarchiveSince, time.Local)
+				if err != nil {
+					return fmt.Errorf("invalid --since date (use YYYY-MM-DD): %w", err)
+				}
+			}
+			for _, record := range records {
+				if !record.Archived.Before(since) {
+					selected = append(selected, record)
+				}
+			}
+		} else {
+			noteID := args[0]
+			if strings.HasPrefix(noteID, "~") {
+				note, err := resolveNoteForWrite(database, noteID)
+				if err != nil {
+					return err
+				}
+				noteID = note.ID
+			}
+			for _, record := range records {
+				if record.NoteID == noteID {
+					selected = append(selected, record)
+				}
+			}
+			if len(selected) == 0 {
+				return fmt.Errorf("no archive record for note %s", noteID)
+			}
+		}
+
+		if len(selected) == 0 {
+			fmt.Println("No archived notes to restore")
+			return nil
+		}
+
+		if unarchiveAll {
+			fmt.Printf("Found %d archived notes:\n", len(selected))
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "  ID\tTITLE\tARCHIVED\tRESTORE TO")
+			for _, record := range selected {
+				fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", record.NoteID, record.Title, record.Archived.Format("2006-01-02"), record.Folder)
+			}
+			w.Flush()
+
+			if !unarchiveYes && !confirm(fmt.Sprintf("Restore %d notes? (y/N): ", len(selected))) {
+				statusf("Unarchive cancelled\n")
+				return nil
+			}
+		}
+
+		created := make(map[string]bool)
+		var done []string
+		restored := 0
+		for _, record := range selected {
+			note, err := database.GetNote(record.NoteID)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: '%s' no longer exists, forgetting it\n", record.Title)
+				done = append(done, record.NoteID)
+				continue
+			}
+			if note.Folder != folderName(record.Archive) {
+				fmt.Fprintf(os.Stderr, "Warning: '%s' was moved out of '%s' since it was archived, leaving it in '%s'\n", note.Title, record.Archive, note.Folder)
+				done = append(done, record.NoteID)
+				continue
+			}
+
+			// Recreate the original folder if it was deleted meanwhile
+			folderID, err := database.FindFolder(record.Folder)
+			if err != nil {
+				return err
+			}
+			if folderID == 0 && !created[record.Folder] {
+				statusf("Recreating folder '%s'...\n", record.Folder)
+				if err := applescript.CreateFolder(cmd.Context(), record.Folder); err != nil {
+					return fmt.Errorf("failed to create folder: %w", err)
+				}
+				if err := verifyFolder(cmd.Context(), database, record.Folder, true); err != nil {
+					return err
+				}
+				created[record.Folder] = true
+			}
+
+			statusf("Moving '%s' back to '%s'...\n", note.Title, record.Folder)
+			if err := moveNoteTo(cmd.Context(), database, "unarchive", note, record.Folder); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to restore '%s': %v\n", note.Title, err)
+				continue
+			}
+			done = append(done, record.NoteID)
+			restored++
+		}
+
+		if dryRun {
+			fmt.Printf("[dry-run] Would restore %d notes\n", restored)
+			return nil
+		}
+		if err := archive.Remove(done...); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to update archive state: %v\n", err)
+		}
+		fmt.Printf("Successfully restored %d notes\n", restored)
+		return nil
+	},
+}
+
+// recordArchive remembers the original folders of archived notes. A failure
+// to write the state file does not fail the archive that already happened.
+func recordArchive(records ...archive.Record) {
+	if dryRun {
+		return
+	}
+	if err := archive.Add(records...); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record archived notes: %v\n", err)
+	}
+}
+
+func init() {
+	unarchiveCmd.Flags().BoolVar(&unarchiveAll, "all", false, "Restore all archived notes")
+	unarchiveCmd.Flags().StringVar(&unarchiveSince, "since", "", "With --all, only restore notes archived on or after this date (YYYY-MM-DD)")
+	unarchiveCmd.Flags().BoolVarP(&unarchiveYes, "yes", "y", false, "Skip confirmation prompt")
+}
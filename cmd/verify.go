@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fishfisher/apple-notes/internal/db"
+)
+
+// verifyTimeout is set by the global --verify-timeout flag. Zero disables
+// verification, and dry runs are never verified.
+var verifyTimeout time.Duration
+
+// writeResult is the outcome of a write operation, printed as JSON with
+// --output json
+type writeResult struct {
+	Action string `json:"action"`
+	ID     string `json:"id,omitempty"`
+	Title  string `json:"title,omitempty"`
+	Folder string `json:"folder,omitempty"`
+	DryRun bool   `json:"dry_run,omitempty"`
+}
+
+// reportWrite prints the outcome of a write operation
+func reportWrite(result writeResult, message string) error {
+	if dryRun {
+		result.DryRun = true
+		message = "[dry-run] No changes made"
+	}
+	if structuredOutput() {
+		return printOutput(result)
+	}
+	if result.ID != "" {
+		fmt.Printf("%s (ID: %s)\n", message, result.ID)
+	} else {
+		fmt.Println(message)
+	}
+	return nil
+}
+
+// noteResult builds a writeResult from a verified note
+func noteResult(action string, note *db.Note) writeResult {
+	if note == nil {
+		return writeResult{Action: action}
+	}
+	return writeResult{Action: action, ID: note.ID, Title: note.Title, Folder: note.Folder}
+}
+
+// verifyCreated waits for a note created after afterID to show up in the
+// database and returns it. It returns nil when verification is disabled.
+func verifyCreated(ctx context.Context, database *db.DB, title, folder string, afterID int64) (*db.Note, error) {
+	if verifyTimeout <= 0 || dryRun {
+		return nil, nil
+	}
+
+	var note *db.Note
+	err := database.WaitFor(ctx, verifyTimeout, func() (bool, error) {
+		var err error
+		note, err = database.FindNoteCreatedAfter(title, folder, afterID)
+		return note != nil, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify new note '%s': %w", title, err)
+	}
+	return note, nil
+}
+
+// verifyModified waits for a note's modification date to move past before
+func verifyModified(ctx context.Context, database *db.DB, id string, before float64) (*db.Note, error) {
+	if verifyTimeout <= 0 || dryRun {
+		return database.GetNote(id)
+	}
+
+	err := database.WaitFor(ctx, verifyTimeout, func() (bool, error) {
+		stamp, err := database.ModificationStamp(id)
+		return stamp > before, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify update of note %s: %w", id, err)
+	}
+	return database.GetNote(id)
+}
+
+// verifyMoved waits for a note to show up in the target folder
+func verifyMoved(ctx context.Context, database *db.DB, id, folder string) (*db.Note, error) {
+	if verifyTimeout <= 0 || dryRun {
+		return database.GetNote(id)
+	}
+
+	var note *db.Note
+	err := database.WaitFor(ctx, verifyTimeout, func() (bool, error) {
+		var err error
+		note, err = database.GetNote(id)
+		return err == nil && note.Folder == folderName(folder), err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify move of note %s: %w", id, err)
+	}
+	return note, nil
+}
+
+// verifyDeleted waits for a note to disappear from the database
+func verifyDeleted(ctx context.Context, database *db.DB, id string) error {
+	if verifyTimeout <= 0 || dryRun {
+		return nil
+	}
+
+	err := database.WaitFor(ctx, verifyTimeout, func() (bool, error) {
+		exists, err := database.NoteExists(id)
+		return !exists, err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to verify deletion of note %s: %w", id, err)
+	}
+	return nil
+}
+
+// verifyFolder waits for a folder path to exist, or to be gone
+func verifyFolder(ctx context.Context, database *db.DB, folderPath string, exists bool) error {
+	if verifyTimeout <= 0 || dryRun {
+		return nil
+	}
+
+	err := database.WaitFor(ctx, verifyTimeout, func() (bool, error) {
+		id, err := database.FindFolder(folderPath)
+		return (id != 0) == exists, err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to verify folder '%s': %w", folderPath, err)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package applescript
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Sentinel errors for the failure classes callers usually need to tell apart.
+// Use errors.Is to match them against errors returned by this package.
+var (
+	ErrPermissionDenied = errors.New("automation permission denied")
+	ErrNotFound         = errors.New("object not found")
+	ErrAppNotRunning    = errors.New("Notes is not running")
+	ErrTimeout          = errors.New("AppleScript timed out")
+)
+
+// osascript error numbers
+const (
+	codeNotAuthorized   = -1743
+	codeCantGet         = -1728
+	codeInvalidIndex    = -1719
+	codeAppNotRunning   = -600
+	codeConnInvalid     = -609
+	codeEventTimedOut   = -1712
+	codeNoUserInterface = -1713
+)
+
+// Error is a classified AppleScript failure
+type Error struct {
+	Code    int    // osascript error number, 0 if unknown
+	Message string // error message reported by osascript
+	kind    error  // one of the sentinel errors, or nil if unclassified
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	if e.kind != nil {
+		b.WriteString(e.kind.Error())
+	} else {
+		b.WriteString("AppleScript error")
+	}
+	if e.Message != "" {
+		b.WriteString(": ")
+		b.WriteString(e.Message)
+	}
+	if e.Code != 0 {
+		fmt.Fprintf(&b, " (%d)", e.Code)
+	}
+	return b.String()
+}
+
+// Unwrap exposes the sentinel error so errors.Is works
+func (e *Error) Unwrap() error {
+	return e.kind
+}
+
+// Hint returns a remediation hint for the user, or an empty string
+func (e *Error) Hint() string {
+	switch e.kind {
+	case ErrPermissionDenied:
+		return "Grant automation access in System Settings > Privacy & Security > Automation, allowing your terminal to control Notes."
+	case ErrNotFound:
+		return "The note or folder no longer exists in Notes. Re-run 'apple-notes list' or 'apple-notes folders' to get current names and IDs."
+	case ErrAppNotRunning:
+		return "Notes could not be reached. Open Notes.app once, then retry."
+	case ErrTimeout:
+		return "Notes did not respond in time. Retry, or raise the limit with --timeout."
+	}
+	return ""
+}
+
+// Transient reports whether the failure is worth retrying
+func (e *Error) Transient() bool {
+	switch e.Code {
+	case codeAppNotRunning, codeConnInvalid, codeEventTimedOut:
+		return true
+	}
+	return false
+}
+
+// Undelivered reports whether the Apple event never reached Notes, so a
+// write can be retried without applying it twice. After a timeout Notes
+// may still have carried out the write.
+func (e *Error) Undelivered() bool {
+	switch e.Code {
+	case codeAppNotRunning, codeConnInvalid:
+		return true
+	}
+	return false
+}
+
+var errorCodePattern = regexp.MustCompile(`\((-?\d+)\)\s*$`)
+
+// classifyError turns osascript's stderr output into an *Error
+func classifyError(ctx context.Context, runErr error, stderr string) *Error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return &Error{Message: "no response from Notes", kind: ErrTimeout}
+	}
+
+	message := strings.TrimSpace(stderr)
+	if message == "" {
+		message = runErr.Error()
+	}
+
+	e := &Error{Message: message}
+	if m := errorCodePattern.FindStringSubmatch(message); m != nil {
+		e.Code, _ = strconv.Atoi(m[1])
+		e.Message = strings.TrimSpace(strings.TrimSuffix(message, m[0]))
+	}
+	// osascript prefixes messages with "<n>:<m>: execution error: "
+	if i := strings.Index(e.Message, "execution error: "); i >= 0 {
+		e.Message = e.Message[i+len("execution error: "):]
+	}
+
+	switch e.Code {
+	case codeNotAuthorized:
+		e.kind = ErrPermissionDenied
+	case codeCantGet, codeInvalidIndex:
+		e.kind = ErrNotFound
+	case codeAppNotRunning, codeConnInvalid, codeNoUserInterface:
+		e.kind = ErrAppNotRunning
+	case codeEventTimedOut:
+		e.kind = ErrTimeout
+	}
+	return e
+}
@@ -1,76 +1,193 @@
 package applescript
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"os/exec"
 	"strings"
+	"time"
 )
 
-// execAppleScript executes an AppleScript and returns the output
-func execAppleScript(script string) (string, error) {
-	cmd := exec.Command("osascript", "-e", script)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return "", fmt.Errorf("AppleScript error: %w\nOutput: %s", err, string(output))
+var (
+	// Timeout bounds a single osascript invocation
+	Timeout = 30 * time.Second
+	// MaxRetries is how many times a transient failure is retried
+	MaxRetries = 2
+	// retryDelay is the base delay between retries, grown linearly per attempt
+	retryDelay = 500 * time.Millisecond
+)
+
+// execAppleScript executes an AppleScript and returns the output. Each attempt
+// is bounded by Timeout, and transient failures are retried up to MaxRetries
+// times. Failures are returned as *Error.
+func execAppleScript(ctx context.Context, script string) (string, error) {
+	return execWithRetry(ctx, script, (*Error).Transient)
+}
+
+// execWithRetry runs a script, retrying failures for which retry is true
+func execWithRetry(ctx context.Context, script string, retry func(*Error) bool) (string, error) {
+	var lastErr *Error
+	for attempt := 0; attempt <= MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return "", lastErr
+			case <-time.After(time.Duration(attempt) * retryDelay):
+			}
+		}
+
+		output, err := runOsascript(ctx, script)
+		if err == nil {
+			return output, nil
+		}
+		lastErr = err
+		if !retry(err) {
+			break
+		}
+	}
+	return "", lastErr
+}
+
+// dryRunKey is the context key holding the dry-run recorder
+type dryRunKey struct{}
+
+// WithDryRun returns a context in which write scripts are handed to record
+// instead of being executed. Read scripts still run normally.
+func WithDryRun(ctx context.Context, record func(script string)) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, record)
+}
+
+// IsDryRun reports whether ctx was created by WithDryRun
+func IsDryRun(ctx context.Context) bool {
+	_, ok := ctx.Value(dryRunKey{}).(func(string))
+	return ok
+}
+
+// execWrite executes a script that modifies notes or folders. Every write
+// goes through here so dry-run mode applies to all of them. Writes are only
+// retried when Notes never received them, since a retry after a timeout can
+// duplicate the write.
+func execWrite(ctx context.Context, script string) (string, error) {
+	if record, ok := ctx.Value(dryRunKey{}).(func(string)); ok {
+		record(script)
+		return "", nil
 	}
-	return strings.TrimSpace(string(output)), nil
+	return execWithRetry(ctx, script, (*Error).Undelivered)
+}
+
+// runOsascript performs a single osascript invocation
+func runOsascript(ctx context.Context, script string) (string, *Error) {
+	if Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, Timeout)
+		defer cancel()
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "osascript", "-e", script)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", classifyError(ctx, err, stderr.String())
+	}
+	return strings.TrimSpace(stdout.String()), nil
 }
 
 // AddNote creates a new note with the given title and body in the specified folder
-func AddNote(title, body, folder string) error {
+func AddNote(ctx context.Context, title, body, folder string) error {
 	script := fmt.Sprintf(`
 		tell application "Notes"
-			tell folder "%s"
+			tell %s
 				make new note with properties {name:"%s", body:"%s"}
 			end tell
 		end tell
-	`, escapeQuotes(folder), escapeQuotes(title), escapeQuotes(body))
+	`, folderRef(folder), escapeQuotes(title), escapeQuotes(body))
+
+	_, err := execWrite(ctx, script)
+	return err
+}
+
+// EditNoteByID updates the title and body of the note with the given
+// AppleScript ID
+func EditNoteByID(ctx context.Context, scriptID, newTitle, newBody string) error {
+	script := fmt.Sprintf(`
+		tell application "Notes"
+			set theNote to note id "%s"
+			set body of theNote to "%s"
+			set name of theNote to "%s"
+		end tell
+	`, escapeQuotes(scriptID), escapeQuotes(newBody), escapeQuotes(newTitle))
+
+	_, err := execWrite(ctx, script)
+	return err
+}
+
+// RecreateNote creates a note from a previously captured title and HTML body
+func RecreateNote(ctx context.Context, title, htmlBody, folder string) error {
+	return AddNoteHTML(ctx, title, htmlBody, folder)
+}
+
+// AddNoteHTML creates a note with an HTML body. Unlike AddNote, backslashes
+// in the title and body are passed through literally.
+func AddNoteHTML(ctx context.Context, title, htmlBody, folder string) error {
+	script := fmt.Sprintf(`
+		tell application "Notes"
+			tell %s
+				set theNote to make new note with properties {body:"%s"}
+				set name of theNote to "%s"
+			end tell
+		end tell
+	`, folderRef(folder), escapeLiteral(htmlBody), escapeLiteral(title))
 
-	_, err := execAppleScript(script)
+	_, err := execWrite(ctx, script)
 	return err
 }
 
-// EditNote updates an existing note's title and/or body
-func EditNote(noteTitle, newTitle, newBody string) error {
+// RestoreNoteContentByID sets a note's title and HTML body verbatim. Unlike
+// EditNoteByID, backslashes in the body are not interpreted as escape
+// sequences.
+func RestoreNoteContentByID(ctx context.Context, scriptID, title, htmlBody string) error {
 	script := fmt.Sprintf(`
 		tell application "Notes"
-			set theNote to first note whose name is "%s"
+			set theNote to note id "%s"
 			set body of theNote to "%s"
 			set name of theNote to "%s"
 		end tell
-	`, escapeQuotes(noteTitle), escapeQuotes(newBody), escapeQuotes(newTitle))
+	`, escapeQuotes(scriptID), escapeLiteral(htmlBody), escapeLiteral(title))
 
-	_, err := execAppleScript(script)
+	_, err := execWrite(ctx, script)
 	return err
 }
 
-// DeleteNote deletes a note by title
-func DeleteNote(noteTitle string) error {
+// DeleteNoteByID deletes a note by its AppleScript ID. Deleted notes go to
+// Recently Deleted.
+func DeleteNoteByID(ctx context.Context, scriptID string) error {
 	script := fmt.Sprintf(`
 		tell application "Notes"
-			delete (first note whose name is "%s")
+			delete note id "%s"
 		end tell
-	`, escapeQuotes(noteTitle))
+	`, escapeQuotes(scriptID))
 
-	_, err := execAppleScript(script)
+	_, err := execWrite(ctx, script)
 	return err
 }
 
-// MoveNote moves a note to a different folder
-func MoveNote(noteTitle, targetFolder string) error {
+// MoveNoteByID moves the note with the given AppleScript ID to a different
+// folder
+func MoveNoteByID(ctx context.Context, scriptID, targetFolder string) error {
 	script := fmt.Sprintf(`
 		tell application "Notes"
-			set theNote to first note whose name is "%s"
-			move theNote to folder "%s"
+			move note id "%s" to %s
 		end tell
-	`, escapeQuotes(noteTitle), escapeQuotes(targetFolder))
+	`, escapeQuotes(scriptID), folderRef(targetFolder))
 
-	_, err := execAppleScript(script)
+	_, err := execWrite(ctx, script)
 	return err
 }
 
 // ListFolderNames returns all folder names
-func ListFolderNames() ([]string, error) {
+func ListFolderNames(ctx context.Context) ([]string, error) {
 	script := `
 		tell application "Notes"
 			set folderList to {}
@@ -81,7 +198,7 @@ func ListFolderNames() ([]string, error) {
 		end tell
 	`
 
-	output, err := execAppleScript(script)
+	output, err := execAppleScript(ctx, script)
 	if err != nil {
 		return nil, err
 	}
@@ -95,21 +212,22 @@ func ListFolderNames() ([]string, error) {
 	return folders, nil
 }
 
-// AppendNote appends content to an existing note
-func AppendNote(noteTitle, content string) error {
+// AppendNoteByID appends content to the note with the given AppleScript ID
+func AppendNoteByID(ctx context.Context, scriptID, content string) error {
 	script := fmt.Sprintf(`
 		tell application "Notes"
-			set theNote to first note whose name is "%s"
+			set theNote to note id "%s"
 			set body of theNote to (body of theNote & "\n%s")
 		end tell
-	`, escapeQuotes(noteTitle), escapeQuotes(content))
+	`, escapeQuotes(scriptID), escapeQuotes(content))
 
-	_, err := execAppleScript(script)
+	_, err := execWrite(ctx, script)
 	return err
 }
 
-// AddTagToNote adds a hashtag to a note (appends it to the body)
-func AddTagToNote(noteTitle, tag string) error {
+// AddTagToNoteByID adds a hashtag to the note with the given AppleScript ID
+// (appends it to the body)
+func AddTagToNoteByID(ctx context.Context, scriptID, tag string) error {
 	// Ensure tag starts with #
 	if !strings.HasPrefix(tag, "#") {
 		tag = "#" + tag
@@ -117,30 +235,30 @@ func AddTagToNote(noteTitle, tag string) error {
 
 	script := fmt.Sprintf(`
 		tell application "Notes"
-			set theNote to first note whose name is "%s"
+			set theNote to note id "%s"
 			set body of theNote to (body of theNote & " %s")
 		end tell
-	`, escapeQuotes(noteTitle), escapeQuotes(tag))
+	`, escapeQuotes(scriptID), escapeQuotes(tag))
 
-	_, err := execAppleScript(script)
+	_, err := execWrite(ctx, script)
 	return err
 }
 
 // BulkMoveNotes moves all notes from a source folder to a target folder
-func BulkMoveNotes(sourceFolder, targetFolder string) (int, error) {
+func BulkMoveNotes(ctx context.Context, sourceFolder, targetFolder string) (int, error) {
 	script := fmt.Sprintf(`
 		tell application "Notes"
 			set movedCount to 0
-			set sourceNotes to notes of folder "%s"
+			set sourceNotes to notes of %s
 			repeat with n in sourceNotes
-				move n to folder "%s"
+				move n to %s
 				set movedCount to movedCount + 1
 			end repeat
 			return movedCount
 		end tell
-	`, escapeQuotes(sourceFolder), escapeQuotes(targetFolder))
+	`, folderRef(sourceFolder), folderRef(targetFolder))
 
-	output, err := execAppleScript(script)
+	output, err := execWrite(ctx, script)
 	if err != nil {
 		return 0, err
 	}
@@ -150,61 +268,131 @@ func BulkMoveNotes(sourceFolder, targetFolder string) (int, error) {
 	return count, nil
 }
 
-// CreateFolder creates a new folder
-func CreateFolder(folderName string) error {
+// CreateFolder creates a new folder. Nested paths such as "Work/Clients/New"
+// are supported; missing parent folders are created along the way.
+func CreateFolder(ctx context.Context, folderPath string) error {
+	parts := SplitFolderPath(folderPath)
+	if len(parts) == 0 {
+		return fmt.Errorf("folder name cannot be empty")
+	}
+
+	names := make([]string, len(parts))
+	for i, part := range parts {
+		names[i] = fmt.Sprintf(`"%s"`, escapeQuotes(part))
+	}
+
 	script := fmt.Sprintf(`
 		tell application "Notes"
-			make new folder with properties {name:"%s"}
+			set parentFolder to missing value
+			repeat with folderName in {%s}
+				set folderName to folderName as text
+				if parentFolder is missing value then
+					if not (exists folder folderName) then
+						make new folder with properties {name:folderName}
+					end if
+					set parentFolder to folder folderName
+				else
+					if not (exists folder folderName of parentFolder) then
+						make new folder at parentFolder with properties {name:folderName}
+					end if
+					set parentFolder to folder folderName of parentFolder
+				end if
+			end repeat
 		end tell
-	`, escapeQuotes(folderName))
+	`, strings.Join(names, ", "))
 
-	_, err := execAppleScript(script)
+	_, err := execWrite(ctx, script)
 	return err
 }
 
-// GetNoteBody retrieves the full plain text body of a note by title
-// If multiple notes have the same title, returns the most recently modified one
-func GetNoteBody(noteTitle string) (string, error) {
+// FolderExists reports whether a folder exists at the given path
+func FolderExists(ctx context.Context, folderPath string) (bool, error) {
 	script := fmt.Sprintf(`
 		tell application "Notes"
-			set matchingNotes to notes whose name is "%s"
-			if (count of matchingNotes) = 0 then
-				error "Note not found"
-			end if
+			return exists %s
+		end tell
+	`, folderRef(folderPath))
 
-			-- Sort by modification date descending to get most recent
-			set mostRecentNote to item 1 of matchingNotes
-			set mostRecentDate to modification date of mostRecentNote
+	output, err := execAppleScript(ctx, script)
+	if err != nil {
+		return false, err
+	}
 
-			repeat with n in matchingNotes
-				if modification date of n > mostRecentDate then
-					set mostRecentNote to n
-					set mostRecentDate to modification date of n
-				end if
-			end repeat
+	return output == "true", nil
+}
 
-			return body of mostRecentNote
+// CountFolderContents returns the number of notes and direct subfolders in a folder
+func CountFolderContents(ctx context.Context, folderPath string) (notes int, subfolders int, err error) {
+	script := fmt.Sprintf(`
+		tell application "Notes"
+			set theFolder to %s
+			return ((count of notes of theFolder) as text) & "," & ((count of folders of theFolder) as text)
 		end tell
-	`, escapeQuotes(noteTitle))
+	`, folderRef(folderPath))
 
-	body, err := execAppleScript(script)
+	output, err := execAppleScript(ctx, script)
 	if err != nil {
-		return "", fmt.Errorf("failed to get note body: %w", err)
+		return 0, 0, err
 	}
 
-	return body, nil
+	if _, err := fmt.Sscanf(output, "%d,%d", &notes, &subfolders); err != nil {
+		return 0, 0, fmt.Errorf("unexpected folder count output: %s", output)
+	}
+	return notes, subfolders, nil
+}
+
+// RenameFolder renames the folder at the given path
+func RenameFolder(ctx context.Context, folderPath, newName string) error {
+	script := fmt.Sprintf(`
+		tell application "Notes"
+			set name of %s to "%s"
+		end tell
+	`, folderRef(folderPath), escapeQuotes(newName))
+
+	_, err := execWrite(ctx, script)
+	return err
+}
+
+// DeleteFolder deletes the folder at the given path
+func DeleteFolder(ctx context.Context, folderPath string) error {
+	script := fmt.Sprintf(`
+		tell application "Notes"
+			delete %s
+		end tell
+	`, folderRef(folderPath))
+
+	_, err := execWrite(ctx, script)
+	return err
+}
+
+// MoveFolder moves a folder into another folder. An empty target moves the
+// folder to the top level of the default account.
+func MoveFolder(ctx context.Context, folderPath, targetParent string) error {
+	target := "default account"
+	if len(SplitFolderPath(targetParent)) > 0 {
+		target = folderRef(targetParent)
+	}
+
+	script := fmt.Sprintf(`
+		tell application "Notes"
+			move %s to %s
+		end tell
+	`, folderRef(folderPath), target)
+
+	_, err := execWrite(ctx, script)
+	return err
 }
 
 // GetNoteBodyByID retrieves the full plain text body of a note by its ID
-func GetNoteBodyByID(noteID string) (string, error) {
+func GetNoteBodyByID(ctx context.Context, noteID string) (string, error) {
 	script := fmt.Sprintf(`
 		tell application "Notes"
-			set theNote to first note whose id is "%s"
+			set theNote to note id "%s"
 			return body of theNote
 		end tell
 	`, escapeQuotes(noteID))
 
-	body, err := execAppleScript(script)
+	body, err := execAppleScript(ctx, script)
 	if err != nil {
 		return "", fmt.Errorf("failed to get note body: %w", err)
 	}
@@ -212,7 +400,41 @@ func GetNoteBodyByID(noteID string) (string, error) {
 	return body, nil
 }
 
+// SplitFolderPath splits a nested folder path like "Work/Clients" into its
+// components, ignoring empty segments
+func SplitFolderPath(folderPath string) []string {
+	var parts []string
+	for _, part := range strings.Split(folderPath, "/") {
+		part = strings.TrimSpace(part)
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// folderRef builds an AppleScript reference to a folder, e.g. "Work/Clients"
+// becomes: folder "Clients" of folder "Work"
+func folderRef(folderPath string) string {
+	parts := SplitFolderPath(folderPath)
+	if len(parts) == 0 {
+		parts = []string{folderPath}
+	}
+
+	refs := make([]string, len(parts))
+	for i, part := range parts {
+		refs[len(parts)-1-i] = fmt.Sprintf(`folder "%s"`, escapeQuotes(part))
+	}
+	return strings.Join(refs, " of ")
+}
+
 // escapeQuotes escapes double quotes for AppleScript
 func escapeQuotes(s string) string {
 	return strings.ReplaceAll(s, `"`, `\"`)
 }
+
+// escapeLiteral escapes backslashes and double quotes so the string reaches
+// Notes unchanged
+func escapeLiteral(s string) string {
+	return escapeQuotes(strings.ReplaceAll(s, `\`, `\\`))
+}
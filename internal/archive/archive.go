@@ -0,0 +1,140 @@
+package archive
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/fishfisher/apple-notes/internal/xdg"
+)
+
+// Record remembers where an archived note came from
+type Record struct {
+	NoteID   string    `json:"note_id"`
+	Title    string    `json:"title"`
+	Folder   string    `json:"folder"`  // original folder path
+	Archive  string    `json:"archive"` // folder the note was archived to
+	Archived time.Time `json:"archived"`
+}
+
+// Path returns the location of the archive state file
+func Path() (string, error) {
+	dir, err := xdg.StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "archive.json"), nil
+}
+
+// Load reads all records, oldest archive first
+func Load() ([]Record, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return []Record{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive state: %w", err)
+	}
+
+	var records []Record
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("failed to parse archive state: %w", err)
+	}
+	return records, nil
+}
+
+// Add stores records, replacing earlier records for the same notes
+func Add(records ...Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	existing, err := Load()
+	if err != nil {
+		return err
+	}
+
+	replaced := make(map[string]bool)
+	for _, record := range records {
+		replaced[record.NoteID] = true
+	}
+	var kept []Record
+	for _, record := range existing {
+		if !replaced[record.NoteID] {
+			kept = append(kept, record)
+		}
+	}
+	for _, record := range records {
+		if record.Archived.IsZero() {
+			record.Archived = time.Now()
+		}
+		kept = append(kept, record)
+	}
+
+	return save(kept)
+}
+
+// Remove forgets the records for the given note IDs
+func Remove(noteIDs ...string) error {
+	if len(noteIDs) == 0 {
+		return nil
+	}
+
+	existing, err := Load()
+	if err != nil {
+		return err
+	}
+
+	removed := make(map[string]bool)
+	for _, id := range noteIDs {
+		removed[id] = true
+	}
+	var kept []Record
+	for _, record := range existing {
+		if !removed[record.NoteID] {
+			kept = append(kept, record)
+		}
+	}
+
+	return save(kept)
+}
+
+// save writes records to the state file, replacing it atomically
+func save(records []Record) error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Archived.Before(records[j].Archived)
+	})
+	if records == nil {
+		records = []Record{}
+	}
+
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode archive state: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write archive state: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write archive state: %w", err)
+	}
+	return nil
+}
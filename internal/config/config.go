@@ -0,0 +1,175 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fishfisher/apple-notes/internal/xdg"
+)
+
+// aliasPrefix marks alias keys, e.g. "alias.standup"
+const aliasPrefix = "alias."
+
+// Config holds flag defaults and command aliases.
+//
+// Defaults are keyed by command path and flag name joined with dots, e.g.
+// "recent.limit" or "template.use.folder". Flags of the root command, such
+// as "output", have no command prefix.
+type Config struct {
+	Defaults map[string]string `json:"defaults,omitempty"`
+	Aliases  map[string]string `json:"aliases,omitempty"`
+}
+
+// Path returns the location of the config file
+func Path() (string, error) {
+	dir, err := xdg.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.json"), nil
+}
+
+// Load reads the config file. A missing file is an empty config.
+func Load() (*Config, error) {
+	cfg := &Config{}
+
+	path, err := Path()
+	if err != nil {
+		return cfg, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read config: %w", err)
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return &Config{}, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Save writes the config file
+func (c *Config) Save() error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	return nil
+}
+
+// IsAlias reports whether key names an alias and returns the alias name
+func IsAlias(key string) (string, bool) {
+	if strings.HasPrefix(key, aliasPrefix) {
+		return strings.TrimPrefix(key, aliasPrefix), true
+	}
+	return "", false
+}
+
+// Get returns the value of a key
+func (c *Config) Get(key string) (string, bool) {
+	if name, ok := IsAlias(key); ok {
+		value, ok := c.Aliases[name]
+		return value, ok
+	}
+	value, ok := c.Defaults[key]
+	return value, ok
+}
+
+// Set stores a value
+func (c *Config) Set(key, value string) {
+	if name, ok := IsAlias(key); ok {
+		if c.Aliases == nil {
+			c.Aliases = make(map[string]string)
+		}
+		c.Aliases[name] = value
+		return
+	}
+	if c.Defaults == nil {
+		c.Defaults = make(map[string]string)
+	}
+	c.Defaults[key] = value
+}
+
+// Unset removes a key, reporting whether it was set
+func (c *Config) Unset(key string) bool {
+	if name, ok := IsAlias(key); ok {
+		_, found := c.Aliases[name]
+		delete(c.Aliases, name)
+		return found
+	}
+	_, found := c.Defaults[key]
+	delete(c.Defaults, key)
+	return found
+}
+
+// Keys returns all keys, defaults first, each group sorted
+func (c *Config) Keys() []string {
+	var defaults, aliases []string
+	for key := range c.Defaults {
+		defaults = append(defaults, key)
+	}
+	for name := range c.Aliases {
+		aliases = append(aliases, aliasPrefix+name)
+	}
+	sort.Strings(defaults)
+	sort.Strings(aliases)
+	return append(defaults, aliases...)
+}
+
+// SplitArgs splits an alias definition into arguments. Single and double
+// quotes group words, as in a shell.
+func SplitArgs(s string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote rune
+
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in %q", s)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,85 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+)
+
+// attachmentTextColumns hold text that Notes recognizes in attachments.
+// Older databases lack some of them.
+var attachmentTextColumns = []string{"ZOCRSUMMARY", "ZHANDWRITINGSUMMARY"}
+
+// ListAttachmentText returns the text recognized in each note's attachments
+// (scanned documents, images, handwriting), keyed by note ID
+func (db *DB) ListAttachmentText() (map[string]string, error) {
+	columns, err := db.objectColumns()
+	if err != nil {
+		return nil, err
+	}
+
+	var present []string
+	for _, column := range attachmentTextColumns {
+		if columns[column] {
+			present = append(present, "COALESCE(attachments."+column+", '')")
+		}
+	}
+	texts := make(map[string]string)
+	if len(present) == 0 {
+		return texts, nil
+	}
+
+	query := `
+		SELECT
+			COALESCE(attachments.ZNOTE, attachments.ZNOTE1),
+			` + strings.Join(present, " || ' ' || ") + `
+		FROM ZICCLOUDSYNCINGOBJECT as attachments
+		WHERE COALESCE(attachments.ZNOTE, attachments.ZNOTE1) IS NOT NULL
+			AND attachments.Z_ENT IN (
+				SELECT Z_ENT FROM Z_PRIMARYKEY
+				WHERE Z_NAME IN ('ICAttachment', 'ICMedia')
+			)
+	`
+
+	rows, err := db.conn.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query attachment text: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, text string
+		if err := rows.Scan(&id, &text); err != nil {
+			return nil, fmt.Errorf("failed to scan attachment text: %w", err)
+		}
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+		if texts[id] != "" {
+			texts[id] += "\n"
+		}
+		texts[id] += text
+	}
+
+	return texts, nil
+}
+
+// objectColumns returns the column names of ZICCLOUDSYNCINGOBJECT, which
+// vary between macOS versions
+func (db *DB) objectColumns() (map[string]bool, error) {
+	rows, err := db.conn.Query("SELECT name FROM pragma_table_info('ZICCLOUDSYNCINGOBJECT')")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read table columns: %w", err)
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan column name: %w", err)
+		}
+		columns[name] = true
+	}
+	return columns, nil
+}
@@ -0,0 +1,36 @@
+package db
+
+import (
+	"database/sql"
+
+	"github.com/fishfisher/apple-notes/internal/textnorm"
+	"github.com/mattn/go-sqlite3"
+)
+
+// driverName is go-sqlite3 with a fold(text) SQL function that applies
+// textnorm.Fold, so conditions can ignore accents and non-ASCII case
+const driverName = "sqlite3_notes"
+
+func init() {
+	sql.Register(driverName, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			return conn.RegisterFunc("fold", textnorm.Fold, true)
+		},
+	})
+}
+
+// SetStrict turns Unicode normalization off for searches, tags and
+// duplicate detection. Text then matches as written, ignoring only ASCII
+// case in searches.
+func (db *DB) SetStrict(strict bool) {
+	db.strict = strict
+}
+
+// matchKey returns the key text is compared by: folded, or as is when
+// strict
+func (db *DB) matchKey(s string) string {
+	if db.strict {
+		return s
+	}
+	return textnorm.Fold(s)
+}
@@ -0,0 +1,247 @@
+package db
+
+import (
+	"bytes"
+	"compress/gzip"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Full note bodies live in ZICNOTEDATA.ZDATA as a gzipped protobuf document.
+// The plain text sits at NoteStoreProto.document(2).note(3).note_text(2);
+// everything else (formatting runs, attachment references) is skipped.
+
+var errNoNoteText = errors.New("note text not found in note data")
+
+// ListNoteBodies retrieves all notes, optionally filtered by folder, with
+// Body set to the decoded plain text. Notes whose data cannot be decoded
+// (e.g. locked notes) fall back to their snippet.
+func (db *DB) ListNoteBodies(folder string) ([]Note, error) {
+	if folder == "" {
+		return db.ListNoteBodiesWhere("")
+	}
+	return db.ListNoteBodiesWhere("folders.ZTITLE2 = ?", folder)
+}
+
+// ListNoteBodiesWhere is ListNoteBodies with an extra WHERE condition on
+// ZICCLOUDSYNCINGOBJECT (the note) and folders (its folder). An empty
+// condition selects all notes.
+func (db *DB) ListNoteBodiesWhere(condition string, args ...interface{}) ([]Note, error) {
+	data, err := db.ListNoteDataWhere(condition, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	notes := make([]Note, len(data))
+	for i, d := range data {
+		notes[i] = d.Decode()
+	}
+	return notes, nil
+}
+
+// NoteData is a note with its stored, still encoded body
+type NoteData struct {
+	Note Note
+	Data []byte
+}
+
+// Decode returns the note with Body set to the decoded plain text, or to
+// the snippet when the data cannot be decoded
+func (d NoteData) Decode() Note {
+	note := d.Note
+	body, err := DecodeNoteData(d.Data)
+	if err != nil {
+		body = note.Snippet
+	}
+	note.Body = body
+	return note
+}
+
+// ListNoteDataWhere retrieves notes matching a WHERE condition like
+// ListNoteBodiesWhere, leaving the bodies encoded so callers can decode them
+// concurrently
+func (db *DB) ListNoteDataWhere(condition string, args ...interface{}) ([]NoteData, error) {
+	query := `
+		SELECT
+			ZICCLOUDSYNCINGOBJECT.Z_PK,
+			COALESCE(ZICCLOUDSYNCINGOBJECT.ZTITLE1, '') as title,
+			COALESCE(ZICCLOUDSYNCINGOBJECT.ZSNIPPET, '') as snippet,
+			COALESCE(folders.ZTITLE2, 'Notes') as folder,
+			COALESCE(datetime(ZICCLOUDSYNCINGOBJECT.ZCREATIONDATE + 978307200, 'unixepoch', 'localtime'), '') as created,
+			COALESCE(datetime(ZICCLOUDSYNCINGOBJECT.ZMODIFICATIONDATE1 + 978307200, 'unixepoch', 'localtime'), '') as modified,
+			notedata.ZDATA
+		FROM ZICCLOUDSYNCINGOBJECT
+		LEFT JOIN ZICCLOUDSYNCINGOBJECT as folders ON ZICCLOUDSYNCINGOBJECT.ZFOLDER = folders.Z_PK
+		LEFT JOIN ZICNOTEDATA as notedata ON notedata.ZNOTE = ZICCLOUDSYNCINGOBJECT.Z_PK
+		WHERE ZICCLOUDSYNCINGOBJECT.ZTITLE1 IS NOT NULL
+			AND ZICCLOUDSYNCINGOBJECT.ZMARKEDFORDELETION = 0
+	`
+
+	if condition != "" {
+		query += " AND " + condition
+	}
+
+	query += " ORDER BY ZICCLOUDSYNCINGOBJECT.ZMODIFICATIONDATE1 DESC"
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query note bodies: %w", err)
+	}
+	defer rows.Close()
+
+	var notes []NoteData
+	for rows.Next() {
+		var d NoteData
+		var createdStr, modifiedStr string
+		err := rows.Scan(&d.Note.ID, &d.Note.Title, &d.Note.Snippet, &d.Note.Folder, &createdStr, &modifiedStr, &d.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan note: %w", err)
+		}
+
+		if createdStr != "" {
+			d.Note.Created, _ = time.ParseInLocation("2006-01-02 15:04:05", createdStr, time.Local)
+		}
+		if modifiedStr != "" {
+			d.Note.Modified, _ = time.ParseInLocation("2006-01-02 15:04:05", modifiedStr, time.Local)
+		}
+		notes = append(notes, d)
+	}
+
+	return notes, nil
+}
+
+// ListNoteSizes returns the size in bytes of each note's stored data,
+// keyed by note ID. Notes without data are missing from the map.
+func (db *DB) ListNoteSizes() (map[string]int64, error) {
+	rows, err := db.conn.Query(`
+		SELECT ZNOTE, LENGTH(ZDATA)
+		FROM ZICNOTEDATA
+		WHERE ZNOTE IS NOT NULL AND ZDATA IS NOT NULL
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query note sizes: %w", err)
+	}
+	defer rows.Close()
+
+	sizes := make(map[string]int64)
+	for rows.Next() {
+		var id string
+		var size int64
+		if err := rows.Scan(&id, &size); err != nil {
+			return nil, fmt.Errorf("failed to scan note size: %w", err)
+		}
+		sizes[id] = size
+	}
+
+	return sizes, nil
+}
+
+// GetNoteText returns the decoded plain text body of a note
+func (db *DB) GetNoteText(id string) (string, error) {
+	var data []byte
+	err := db.conn.QueryRow(`
+		SELECT ZDATA
+		FROM ZICNOTEDATA
+		WHERE ZNOTE = ?
+	`, id).Scan(&data)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", fmt.Errorf("note data not found: %s", id)
+		}
+		return "", fmt.Errorf("failed to get note data: %w", err)
+	}
+
+	return DecodeNoteData(data)
+}
+
+// DecodeNoteData extracts the plain text from a ZICNOTEDATA.ZDATA blob
+func DecodeNoteData(data []byte) (string, error) {
+	if len(data) == 0 {
+		return "", errNoNoteText
+	}
+
+	if len(data) > 2 && data[0] == 0x1f && data[1] == 0x8b {
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return "", fmt.Errorf("failed to decompress note data: %w", err)
+		}
+		defer zr.Close()
+
+		data, err = io.ReadAll(zr)
+		if err != nil {
+			return "", fmt.Errorf("failed to decompress note data: %w", err)
+		}
+	}
+
+	// NoteStoreProto -> Document (2) -> Note (3) -> note_text (2)
+	for _, field := range []int{2, 3, 2} {
+		var ok bool
+		data, ok = protoField(data, field)
+		if !ok {
+			return "", errNoNoteText
+		}
+	}
+
+	return string(data), nil
+}
+
+// protoField returns the payload of the first length-delimited field with
+// the given number in a protobuf message
+func protoField(msg []byte, field int) ([]byte, bool) {
+	for len(msg) > 0 {
+		key, n := protoVarint(msg)
+		if n == 0 {
+			return nil, false
+		}
+		msg = msg[n:]
+
+		num, wireType := int(key>>3), key&0x7
+		switch wireType {
+		case 0: // varint
+			_, n := protoVarint(msg)
+			if n == 0 {
+				return nil, false
+			}
+			msg = msg[n:]
+		case 1: // 64-bit
+			if len(msg) < 8 {
+				return nil, false
+			}
+			msg = msg[8:]
+		case 2: // length-delimited
+			length, n := protoVarint(msg)
+			if n == 0 || uint64(len(msg)-n) < length {
+				return nil, false
+			}
+			payload := msg[n : n+int(length)]
+			if num == field {
+				return payload, true
+			}
+			msg = msg[n+int(length):]
+		case 5: // 32-bit
+			if len(msg) < 4 {
+				return nil, false
+			}
+			msg = msg[4:]
+		default:
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// protoVarint decodes a varint, returning the value and the bytes consumed
+// (0 if the input is truncated)
+func protoVarint(buf []byte) (uint64, int) {
+	var value uint64
+	for i := 0; i < len(buf) && i < 10; i++ {
+		value |= uint64(buf[i]&0x7f) << (7 * uint(i))
+		if buf[i] < 0x80 {
+			return value, i + 1
+		}
+	}
+	return 0, 0
+}
@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/fishfisher/apple-notes/internal/fuzzy"
 	_ "github.com/mattn/go-sqlite3"
 )
 
@@ -47,8 +48,18 @@ type Stats struct {
 	TotalCharacters int64
 }
 
+// NoteAttributes holds per-note flags that are not part of Note
+type NoteAttributes struct {
+	Pinned      bool
+	Locked      bool
+	Attachments int
+	// FolderID identifies the note's folder, as returned by FindFolder
+	FolderID int64
+}
+
 type DB struct {
-	conn *sql.DB
+	conn   *sql.DB
+	strict bool
 }
 
 // GetNotesDB returns the path to the Apple Notes database
@@ -74,7 +85,7 @@ func Open() (*DB, error) {
 	}
 
 	// Open in read-only mode to avoid locking issues
-	conn, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?mode=ro", dbPath))
+	conn, err := sql.Open(driverName, fmt.Sprintf("file:%s?mode=ro", dbPath))
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -89,100 +100,14 @@ func (db *DB) Close() error {
 
 // ListNotes retrieves all notes, optionally filtered by folder
 func (db *DB) ListNotes(folder string) ([]Note, error) {
-	query := `
-		SELECT
-			ZICCLOUDSYNCINGOBJECT.Z_PK,
-			COALESCE(ZICCLOUDSYNCINGOBJECT.ZTITLE1, '') as title,
-			COALESCE(ZICCLOUDSYNCINGOBJECT.ZSNIPPET, '') as snippet,
-			COALESCE(folders.ZTITLE2, 'Notes') as folder,
-			COALESCE(datetime(ZICCLOUDSYNCINGOBJECT.ZCREATIONDATE + 978307200, 'unixepoch', 'localtime'), '') as created,
-			COALESCE(datetime(ZICCLOUDSYNCINGOBJECT.ZMODIFICATIONDATE1 + 978307200, 'unixepoch', 'localtime'), '') as modified
-		FROM ZICCLOUDSYNCINGOBJECT
-		LEFT JOIN ZICCLOUDSYNCINGOBJECT as folders ON ZICCLOUDSYNCINGOBJECT.ZFOLDER = folders.Z_PK
-		WHERE ZICCLOUDSYNCINGOBJECT.ZTITLE1 IS NOT NULL
-			AND ZICCLOUDSYNCINGOBJECT.ZMARKEDFORDELETION = 0
-	`
-
-	var args []interface{}
-	if folder != "" {
-		query += " AND folders.ZTITLE2 = ?"
-		args = append(args, folder)
-	}
-
-	query += " ORDER BY ZICCLOUDSYNCINGOBJECT.ZMODIFICATIONDATE1 DESC"
-
-	rows, err := db.conn.Query(query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query notes: %w", err)
-	}
-	defer rows.Close()
-
-	var notes []Note
-	for rows.Next() {
-		var note Note
-		var createdStr, modifiedStr string
-		err := rows.Scan(&note.ID, &note.Title, &note.Snippet, &note.Folder, &createdStr, &modifiedStr)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan note: %w", err)
-		}
-
-		if createdStr != "" {
-			note.Created, _ = time.Parse("2006-01-02 15:04:05", createdStr)
-		}
-		if modifiedStr != "" {
-			note.Modified, _ = time.Parse("2006-01-02 15:04:05", modifiedStr)
-		}
-		notes = append(notes, note)
-	}
-
-	return notes, nil
+	notes, _, err := db.ListNotesPage(folder, Page{})
+	return notes, err
 }
 
 // SearchNotes searches for notes containing the search term in title or snippet
 func (db *DB) SearchNotes(term string) ([]Note, error) {
-	query := `
-		SELECT
-			ZICCLOUDSYNCINGOBJECT.Z_PK,
-			COALESCE(ZICCLOUDSYNCINGOBJECT.ZTITLE1, '') as title,
-			COALESCE(ZICCLOUDSYNCINGOBJECT.ZSNIPPET, '') as snippet,
-			COALESCE(folders.ZTITLE2, 'Notes') as folder,
-			COALESCE(datetime(ZICCLOUDSYNCINGOBJECT.ZCREATIONDATE + 978307200, 'unixepoch', 'localtime'), '') as created,
-			COALESCE(datetime(ZICCLOUDSYNCINGOBJECT.ZMODIFICATIONDATE1 + 978307200, 'unixepoch', 'localtime'), '') as modified
-		FROM ZICCLOUDSYNCINGOBJECT
-		LEFT JOIN ZICCLOUDSYNCINGOBJECT as folders ON ZICCLOUDSYNCINGOBJECT.ZFOLDER = folders.Z_PK
-		WHERE ZICCLOUDSYNCINGOBJECT.ZTITLE1 IS NOT NULL
-			AND ZICCLOUDSYNCINGOBJECT.ZMARKEDFORDELETION = 0
-			AND (ZICCLOUDSYNCINGOBJECT.ZTITLE1 LIKE ? OR ZICCLOUDSYNCINGOBJECT.ZSNIPPET LIKE ?)
-		ORDER BY ZICCLOUDSYNCINGOBJECT.ZMODIFICATIONDATE1 DESC
-		LIMIT 100
-	`
-
-	searchPattern := "%" + term + "%"
-	rows, err := db.conn.Query(query, searchPattern, searchPattern)
-	if err != nil {
-		return nil, fmt.Errorf("failed to search notes: %w", err)
-	}
-	defer rows.Close()
-
-	var notes []Note
-	for rows.Next() {
-		var note Note
-		var createdStr, modifiedStr string
-		err := rows.Scan(&note.ID, &note.Title, &note.Snippet, &note.Folder, &createdStr, &modifiedStr)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan note: %w", err)
-		}
-
-		if createdStr != "" {
-			note.Created, _ = time.Parse("2006-01-02 15:04:05", createdStr)
-		}
-		if modifiedStr != "" {
-			note.Modified, _ = time.Parse("2006-01-02 15:04:05", modifiedStr)
-		}
-		notes = append(notes, note)
-	}
-
-	return notes, nil
+	notes, _, err := db.SearchNotesPage(term, Page{})
+	return notes, err
 }
 
 // GetNote retrieves a specific note by ID only
@@ -216,54 +141,90 @@ func (db *DB) GetNote(id string) (*Note, error) {
 	}
 
 	if createdStr != "" {
-		note.Created, _ = time.Parse("2006-01-02 15:04:05", createdStr)
+		note.Created, _ = time.ParseInLocation("2006-01-02 15:04:05", createdStr, time.Local)
 	}
 	if modifiedStr != "" {
-		note.Modified, _ = time.Parse("2006-01-02 15:04:05", modifiedStr)
+		note.Modified, _ = time.ParseInLocation("2006-01-02 15:04:05", modifiedStr, time.Local)
 	}
 
 	return &note, nil
 }
 
-// GetNoteByTitle retrieves a note by title only (kept for internal use)
-func (db *DB) GetNoteByTitle(title string) (*Note, error) {
-	query := `
-		SELECT
-			ZICCLOUDSYNCINGOBJECT.Z_PK,
-			COALESCE(ZICCLOUDSYNCINGOBJECT.ZTITLE1, '') as title,
-			COALESCE(ZICCLOUDSYNCINGOBJECT.ZSNIPPET, '') as snippet,
-			COALESCE(folders.ZTITLE2, 'Notes') as folder,
-			COALESCE(datetime(ZICCLOUDSYNCINGOBJECT.ZCREATIONDATE + 978307200, 'unixepoch', 'localtime'), '') as created,
-			COALESCE(datetime(ZICCLOUDSYNCINGOBJECT.ZMODIFICATIONDATE1 + 978307200, 'unixepoch', 'localtime'), '') as modified
-		FROM ZICCLOUDSYNCINGOBJECT
-		LEFT JOIN ZICCLOUDSYNCINGOBJECT as folders ON ZICCLOUDSYNCINGOBJECT.ZFOLDER = folders.Z_PK
-		WHERE ZICCLOUDSYNCINGOBJECT.ZMARKEDFORDELETION = 0
-			AND ZICCLOUDSYNCINGOBJECT.ZTITLE1 = ?
-		ORDER BY ZICCLOUDSYNCINGOBJECT.ZMODIFICATIONDATE1 DESC
-		LIMIT 1
-	`
+// AppleScriptID returns the identifier Notes.app uses for a note in
+// AppleScript, e.g. "x-coredata://<store-uuid>/ICNote/p4318". Unlike titles
+// it is unique, so scripts can target exactly one note.
+func (db *DB) AppleScriptID(id string) (string, error) {
+	var storeUUID string
+	err := db.conn.QueryRow(`SELECT Z_UUID FROM Z_METADATA LIMIT 1`).Scan(&storeUUID)
+	if err != nil {
+		return "", fmt.Errorf("failed to read store identifier: %w", err)
+	}
 
-	var note Note
-	var createdStr, modifiedStr string
+	return fmt.Sprintf("x-coredata://%s/ICNote/p%s", storeUUID, id), nil
+}
 
-	err := db.conn.QueryRow(query, title).Scan(
-		&note.ID, &note.Title, &note.Snippet, &note.Folder, &createdStr, &modifiedStr,
-	)
+// NoteFolderPath returns the full path of a note's folder, e.g. "Work/Clients",
+// by following the folder's parent chain. Notes outside any folder are in
+// "Notes".
+func (db *DB) NoteFolderPath(id string) (string, error) {
+	var folderID sql.NullInt64
+	err := db.conn.QueryRow(`SELECT ZFOLDER FROM ZICCLOUDSYNCINGOBJECT WHERE Z_PK = ?`, id).Scan(&folderID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("note not found: %s", title)
+			return "", fmt.Errorf("note not found: %s", id)
 		}
-		return nil, fmt.Errorf("failed to get note: %w", err)
+		return "", fmt.Errorf("failed to get note folder: %w", err)
+	}
+	if !folderID.Valid {
+		return "Notes", nil
+	}
+
+	var parts []string
+	current := folderID.Int64
+	// The depth limit guards against parent cycles in a damaged store
+	for depth := 0; current != 0 && depth < 32; depth++ {
+		var name sql.NullString
+		var parent sql.NullInt64
+		err := db.conn.QueryRow(`
+			SELECT ZTITLE2, ZPARENT
+			FROM ZICCLOUDSYNCINGOBJECT
+			WHERE Z_PK = ?
+		`, current).Scan(&name, &parent)
+		if err == sql.ErrNoRows || !name.Valid {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to look up folder: %w", err)
+		}
+		parts = append([]string{name.String}, parts...)
+		current = parent.Int64
 	}
 
-	if createdStr != "" {
-		note.Created, _ = time.Parse("2006-01-02 15:04:05", createdStr)
+	if len(parts) == 0 {
+		return "Notes", nil
 	}
-	if modifiedStr != "" {
-		note.Modified, _ = time.Parse("2006-01-02 15:04:05", modifiedStr)
+	return strings.Join(parts, "/"), nil
+}
+
+// FindNotesByTitle returns the notes whose titles best match a fuzzy query
+// such as a partial or misspelled title (see package fuzzy), best first.
+// Equally good matches are ordered most recently modified first.
+func (db *DB) FindNotesByTitle(query string) ([]Note, error) {
+	notes, err := db.ListNotes("")
+	if err != nil {
+		return nil, err
 	}
 
-	return &note, nil
+	titles := make([]string, len(notes))
+	for i, note := range notes {
+		titles[i] = note.Title
+	}
+
+	var matches []Note
+	for _, match := range fuzzy.Top(fuzzy.Rank(query, titles)) {
+		matches = append(matches, notes[match.Index])
+	}
+	return matches, nil
 }
 
 // CountNotesByTitle counts how many notes have the given title
@@ -326,6 +287,47 @@ func (db *DB) HasRichContent(noteIdentifier string) (bool, error) {
 	return attachmentCount > 0, nil
 }
 
+// ListNoteAttributes returns the attributes of every note, keyed by note ID
+func (db *DB) ListNoteAttributes() (map[string]NoteAttributes, error) {
+	query := `
+		SELECT
+			notes.Z_PK,
+			COALESCE(notes.ZISPINNED, 0),
+			COALESCE(notes.ZISPASSWORDPROTECTED, 0),
+			COALESCE(notes.ZFOLDER, 0),
+			(
+				SELECT COUNT(*)
+				FROM ZICCLOUDSYNCINGOBJECT as attachments
+				WHERE (attachments.ZNOTE = notes.Z_PK OR attachments.ZNOTE1 = notes.Z_PK)
+					AND attachments.Z_ENT IN (
+						SELECT Z_ENT FROM Z_PRIMARYKEY
+						WHERE Z_NAME IN ('ICAttachment', 'ICMedia', 'ICTable')
+					)
+			) as attachment_count
+		FROM ZICCLOUDSYNCINGOBJECT as notes
+		WHERE notes.ZTITLE1 IS NOT NULL
+			AND notes.ZMARKEDFORDELETION = 0
+	`
+
+	rows, err := db.conn.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query note attributes: %w", err)
+	}
+	defer rows.Close()
+
+	attrs := make(map[string]NoteAttributes)
+	for rows.Next() {
+		var id string
+		var a NoteAttributes
+		if err := rows.Scan(&id, &a.Pinned, &a.Locked, &a.FolderID, &a.Attachments); err != nil {
+			return nil, fmt.Errorf("failed to scan note attributes: %w", err)
+		}
+		attrs[id] = a
+	}
+
+	return attrs, nil
+}
+
 // ListFolders retrieves all note folders with note counts
 func (db *DB) ListFolders() ([]Folder, error) {
 	query := `
@@ -394,10 +396,10 @@ func (db *DB) GetRecentNotes(days int, limit int) ([]Note, error) {
 		}
 
 		if createdStr != "" {
-			note.Created, _ = time.Parse("2006-01-02 15:04:05", createdStr)
+			note.Created, _ = time.ParseInLocation("2006-01-02 15:04:05", createdStr, time.Local)
 		}
 		if modifiedStr != "" {
-			note.Modified, _ = time.Parse("2006-01-02 15:04:05", modifiedStr)
+			note.Modified, _ = time.ParseInLocation("2006-01-02 15:04:05", modifiedStr, time.Local)
 		}
 		notes = append(notes, note)
 	}
@@ -421,7 +423,9 @@ func (db *DB) ExtractTags() ([]Tag, error) {
 	}
 	defer rows.Close()
 
-	tagCounts := make(map[string]int)
+	// Tags that only differ in case or accents are counted together and
+	// shown with their most common spelling
+	spellings := make(map[string]map[string]int)
 	for rows.Next() {
 		var snippet string
 		if err := rows.Scan(&snippet); err != nil {
@@ -431,14 +435,26 @@ func (db *DB) ExtractTags() ([]Tag, error) {
 		// Extract hashtags from snippet
 		tags := extractHashtags(snippet)
 		for _, tag := range tags {
-			tagCounts[tag]++
+			key := db.matchKey(tag)
+			if spellings[key] == nil {
+				spellings[key] = make(map[string]int)
+			}
+			spellings[key][tag]++
 		}
 	}
 
 	// Convert map to slice
 	var tags []Tag
-	for name, count := range tagCounts {
-		tags = append(tags, Tag{Name: name, Count: count})
+	for _, counts := range spellings {
+		var tag Tag
+		best := 0
+		for name, count := range counts {
+			tag.Count += count
+			if count > best || (count == best && name < tag.Name) {
+				tag.Name, best = name, count
+			}
+		}
+		tags = append(tags, tag)
 	}
 
 	// Sort by count descending
@@ -451,12 +467,8 @@ func (db *DB) ExtractTags() ([]Tag, error) {
 
 // SearchByTag searches for notes containing a specific hashtag
 func (db *DB) SearchByTag(tag string) ([]Note, error) {
-	// Ensure tag starts with #
-	if !strings.HasPrefix(tag, "#") {
-		tag = "#" + tag
-	}
-
-	return db.SearchNotes(tag)
+	notes, _, err := db.SearchByTagPage(tag, Page{})
+	return notes, err
 }
 
 // GetStats returns statistics about the notes collection
@@ -508,10 +520,11 @@ func (db *DB) GetStats() (*Stats, error) {
 	}
 
 	// Largest note
-	var title, snippet, folder string
+	var id, title, snippet, folder string
 	var createdStr, modifiedStr string
 	err = db.conn.QueryRow(`
 		SELECT
+			Z_PK as id,
 			COALESCE(ZTITLE1, '') as title,
 			COALESCE(ZSNIPPET, '') as snippet,
 			COALESCE((SELECT ZTITLE2 FROM ZICCLOUDSYNCINGOBJECT WHERE Z_PK = n.ZFOLDER), 'Notes') as folder,
@@ -524,17 +537,17 @@ func (db *DB) GetStats() (*Stats, error) {
 			AND ZSNIPPET IS NOT NULL
 		ORDER BY len DESC
 		LIMIT 1
-	`).Scan(&title, &snippet, &folder, &createdStr, &modifiedStr, &stats.TotalCharacters)
+	`).Scan(&id, &title, &snippet, &folder, &createdStr, &modifiedStr, &stats.TotalCharacters)
 	if err != nil && err != sql.ErrNoRows {
 		return nil, fmt.Errorf("failed to find largest note: %w", err)
 	}
 	if err == nil {
-		stats.LargestNote = Note{Title: title, Snippet: snippet, Folder: folder}
+		stats.LargestNote = Note{ID: id, Title: title, Snippet: snippet, Folder: folder}
 		if createdStr != "" {
-			stats.LargestNote.Created, _ = time.Parse("2006-01-02 15:04:05", createdStr)
+			stats.LargestNote.Created, _ = time.ParseInLocation("2006-01-02 15:04:05", createdStr, time.Local)
 		}
 		if modifiedStr != "" {
-			stats.LargestNote.Modified, _ = time.Parse("2006-01-02 15:04:05", modifiedStr)
+			stats.LargestNote.Modified, _ = time.ParseInLocation("2006-01-02 15:04:05", modifiedStr, time.Local)
 		}
 	}
 
@@ -549,7 +562,8 @@ func (db *DB) GetStats() (*Stats, error) {
 	return stats, nil
 }
 
-// FindDuplicates finds notes with identical or very similar titles
+// FindDuplicates finds notes with identical titles, ignoring case and
+// accents unless strict
 func (db *DB) FindDuplicates() ([][]Note, error) {
 	query := `
 		SELECT
@@ -582,13 +596,14 @@ func (db *DB) FindDuplicates() ([][]Note, error) {
 		}
 
 		if createdStr != "" {
-			note.Created, _ = time.Parse("2006-01-02 15:04:05", createdStr)
+			note.Created, _ = time.ParseInLocation("2006-01-02 15:04:05", createdStr, time.Local)
 		}
 		if modifiedStr != "" {
-			note.Modified, _ = time.Parse("2006-01-02 15:04:05", modifiedStr)
+			note.Modified, _ = time.ParseInLocation("2006-01-02 15:04:05", modifiedStr, time.Local)
 		}
 
-		titleMap[note.Title] = append(titleMap[note.Title], note)
+		key := db.matchKey(note.Title)
+		titleMap[key] = append(titleMap[key], note)
 	}
 
 	// Find duplicates
@@ -614,48 +629,13 @@ func (db *DB) ExtractLinks(noteIdentifier string) ([]string, error) {
 
 // FindNotesWithLinks finds all notes that contain URLs
 func (db *DB) FindNotesWithLinks() ([]Note, error) {
-	query := `
-		SELECT
-			ZICCLOUDSYNCINGOBJECT.Z_PK,
-			COALESCE(ZICCLOUDSYNCINGOBJECT.ZTITLE1, '') as title,
-			COALESCE(ZICCLOUDSYNCINGOBJECT.ZSNIPPET, '') as snippet,
-			COALESCE(folders.ZTITLE2, 'Notes') as folder,
-			COALESCE(datetime(ZICCLOUDSYNCINGOBJECT.ZCREATIONDATE + 978307200, 'unixepoch', 'localtime'), '') as created,
-			COALESCE(datetime(ZICCLOUDSYNCINGOBJECT.ZMODIFICATIONDATE1 + 978307200, 'unixepoch', 'localtime'), '') as modified
-		FROM ZICCLOUDSYNCINGOBJECT
-		LEFT JOIN ZICCLOUDSYNCINGOBJECT as folders ON ZICCLOUDSYNCINGOBJECT.ZFOLDER = folders.Z_PK
-		WHERE ZICCLOUDSYNCINGOBJECT.ZTITLE1 IS NOT NULL
-			AND ZICCLOUDSYNCINGOBJECT.ZMARKEDFORDELETION = 0
-			AND (ZICCLOUDSYNCINGOBJECT.ZSNIPPET LIKE '%http://%' OR ZICCLOUDSYNCINGOBJECT.ZSNIPPET LIKE '%https://%')
-		ORDER BY ZICCLOUDSYNCINGOBJECT.ZMODIFICATIONDATE1 DESC
-	`
-
-	rows, err := db.conn.Query(query)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query notes with links: %w", err)
-	}
-	defer rows.Close()
-
-	var notes []Note
-	for rows.Next() {
-		var note Note
-		var createdStr, modifiedStr string
-		err := rows.Scan(&note.ID, &note.Title, &note.Snippet, &note.Folder, &createdStr, &modifiedStr)
-		if err != nil {
-			continue
-		}
-
-		if createdStr != "" {
-			note.Created, _ = time.Parse("2006-01-02 15:04:05", createdStr)
-		}
-		if modifiedStr != "" {
-			note.Modified, _ = time.Parse("2006-01-02 15:04:05", modifiedStr)
-		}
-
-		notes = append(notes, note)
-	}
+	notes, _, err := db.FindNotesWithLinksPage(Page{})
+	return notes, err
+}
 
-	return notes, nil
+// Hashtags returns the hashtags in text, in order of appearance
+func Hashtags(text string) []string {
+	return extractHashtags(text)
 }
 
 // Helper functions
@@ -0,0 +1,190 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fishfisher/apple-notes/internal/textnorm"
+)
+
+// Page controls the order and range of notes returned by the paged queries
+type Page struct {
+	// Sort is one of SortKeys; empty sorts by modification date
+	Sort    string
+	Reverse bool
+	// Limit is the maximum number of notes to return; 0 returns all
+	Limit  int
+	Offset int
+}
+
+// SortKeys are the values accepted by Page.Sort. Titles and folders sort
+// A-Z, dates newest first and sizes largest first.
+var SortKeys = []string{"title", "created", "modified", "size", "folder"}
+
+// orderBy returns the ORDER BY clause for the page
+func (p Page) orderBy() (string, error) {
+	var column, direction string
+	switch p.Sort {
+	case "title":
+		column, direction = "ZICCLOUDSYNCINGOBJECT.ZTITLE1 COLLATE NOCASE", "ASC"
+	case "folder":
+		column, direction = "folder COLLATE NOCASE", "ASC"
+	case "created":
+		column, direction = "ZICCLOUDSYNCINGOBJECT.ZCREATIONDATE", "DESC"
+	case "", "modified":
+		column, direction = "ZICCLOUDSYNCINGOBJECT.ZMODIFICATIONDATE1", "DESC"
+	case "size":
+		column, direction = "LENGTH(notedata.ZDATA)", "DESC"
+	default:
+		return "", fmt.Errorf("invalid sort %q (use %s)", p.Sort, strings.Join(SortKeys, ", "))
+	}
+
+	if p.Reverse {
+		if direction == "ASC" {
+			direction = "DESC"
+		} else {
+			direction = "ASC"
+		}
+	}
+	return fmt.Sprintf(" ORDER BY %s %s, ZICCLOUDSYNCINGOBJECT.Z_PK %s", column, direction, direction), nil
+}
+
+// ListNotesPage retrieves one page of notes, optionally filtered by folder,
+// and the total number of matching notes
+func (db *DB) ListNotesPage(folder string, page Page) ([]Note, int, error) {
+	if folder == "" {
+		return db.queryNotes("", nil, page)
+	}
+	return db.queryNotes("folders.ZTITLE2 = ?", []interface{}{folder}, page)
+}
+
+// ListNotesInFolder retrieves the notes directly in the folder with the
+// given ID, as returned by FindFolder. Unlike ListNotes, folders elsewhere
+// with the same name are not included.
+func (db *DB) ListNotesInFolder(folderID int64) ([]Note, error) {
+	notes, _, err := db.queryNotes("ZICCLOUDSYNCINGOBJECT.ZFOLDER = ?", []interface{}{folderID}, Page{})
+	return notes, err
+}
+
+// SearchNotesPage retrieves one page of notes containing term in their
+// title or snippet, and the total number of matching notes. Case and
+// accents are ignored unless strict.
+func (db *DB) SearchNotesPage(term string, page Page) ([]Note, int, error) {
+	if db.strict {
+		pattern := "%" + term + "%"
+		return db.queryNotes(
+			"(ZICCLOUDSYNCINGOBJECT.ZTITLE1 LIKE ? OR ZICCLOUDSYNCINGOBJECT.ZSNIPPET LIKE ?)",
+			[]interface{}{pattern, pattern},
+			page,
+		)
+	}
+
+	pattern := "%" + textnorm.Fold(term) + "%"
+	return db.queryNotes(
+		"(fold(ZICCLOUDSYNCINGOBJECT.ZTITLE1) LIKE ? OR fold(COALESCE(ZICCLOUDSYNCINGOBJECT.ZSNIPPET, '')) LIKE ?)",
+		[]interface{}{pattern, pattern},
+		page,
+	)
+}
+
+// SearchByTagPage retrieves one page of notes containing a hashtag, and
+// the total number of matching notes
+func (db *DB) SearchByTagPage(tag string, page Page) ([]Note, int, error) {
+	if !strings.HasPrefix(tag, "#") {
+		tag = "#" + tag
+	}
+	return db.SearchNotesPage(tag, page)
+}
+
+// FindNotesWithLinksPage retrieves one page of notes whose snippet contains
+// a URL, and the total number of matching notes
+func (db *DB) FindNotesWithLinksPage(page Page) ([]Note, int, error) {
+	return db.queryNotes(
+		"(ZICCLOUDSYNCINGOBJECT.ZSNIPPET LIKE '%http://%' OR ZICCLOUDSYNCINGOBJECT.ZSNIPPET LIKE '%https://%')",
+		nil,
+		page,
+	)
+}
+
+// FindNotesPage retrieves one page of notes matching a WHERE condition on
+// ZICCLOUDSYNCINGOBJECT (the note) and folders (its folder), and the total
+// number of matching notes
+func (db *DB) FindNotesPage(condition string, args []interface{}, page Page) ([]Note, int, error) {
+	return db.queryNotes(condition, args, page)
+}
+
+// queryNotes runs a note query with an extra WHERE condition, applying the
+// page's order, limit and offset in SQL. It also returns the number of
+// notes matching the condition.
+func (db *DB) queryNotes(condition string, args []interface{}, page Page) ([]Note, int, error) {
+	orderBy, err := page.orderBy()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	from := `
+		FROM ZICCLOUDSYNCINGOBJECT
+		LEFT JOIN ZICCLOUDSYNCINGOBJECT as folders ON ZICCLOUDSYNCINGOBJECT.ZFOLDER = folders.Z_PK`
+	if page.Sort == "size" {
+		from += `
+		LEFT JOIN ZICNOTEDATA as notedata ON notedata.ZNOTE = ZICCLOUDSYNCINGOBJECT.Z_PK`
+	}
+	from += `
+		WHERE ZICCLOUDSYNCINGOBJECT.ZTITLE1 IS NOT NULL
+			AND ZICCLOUDSYNCINGOBJECT.ZMARKEDFORDELETION = 0
+	`
+	if condition != "" {
+		from += " AND " + condition
+	}
+
+	var total int
+	if err := db.conn.QueryRow("SELECT COUNT(*)"+from, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count notes: %w", err)
+	}
+
+	query := `
+		SELECT
+			ZICCLOUDSYNCINGOBJECT.Z_PK,
+			COALESCE(ZICCLOUDSYNCINGOBJECT.ZTITLE1, '') as title,
+			COALESCE(ZICCLOUDSYNCINGOBJECT.ZSNIPPET, '') as snippet,
+			COALESCE(folders.ZTITLE2, 'Notes') as folder,
+			COALESCE(datetime(ZICCLOUDSYNCINGOBJECT.ZCREATIONDATE + 978307200, 'unixepoch', 'localtime'), '') as created,
+			COALESCE(datetime(ZICCLOUDSYNCINGOBJECT.ZMODIFICATIONDATE1 + 978307200, 'unixepoch', 'localtime'), '') as modified
+	` + from + orderBy
+
+	if page.Limit > 0 || page.Offset > 0 {
+		limit := page.Limit
+		if limit <= 0 {
+			limit = -1
+		}
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, limit, page.Offset)
+	}
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query notes: %w", err)
+	}
+	defer rows.Close()
+
+	var notes []Note
+	for rows.Next() {
+		var note Note
+		var createdStr, modifiedStr string
+		err := rows.Scan(&note.ID, &note.Title, &note.Snippet, &note.Folder, &createdStr, &modifiedStr)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan note: %w", err)
+		}
+
+		if createdStr != "" {
+			note.Created, _ = time.ParseInLocation("2006-01-02 15:04:05", createdStr, time.Local)
+		}
+		if modifiedStr != "" {
+			note.Modified, _ = time.ParseInLocation("2006-01-02 15:04:05", modifiedStr, time.Local)
+		}
+		notes = append(notes, note)
+	}
+
+	return notes, total, nil
+}
@@ -0,0 +1,159 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrNotVerified is returned when a write did not become visible in the
+// database before the verification timeout
+var ErrNotVerified = errors.New("change not visible in the notes database")
+
+// pollInterval is how often WaitFor re-checks the database
+const pollInterval = 250 * time.Millisecond
+
+// WaitFor polls check until it reports true, it fails, or the timeout expires
+func (db *DB) WaitFor(ctx context.Context, timeout time.Duration, check func() (bool, error)) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		ok, err := check()
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w after %s", ErrNotVerified, timeout)
+		case <-ticker.C:
+		}
+	}
+}
+
+// MaxObjectID returns the highest primary key in the object table. New notes
+// and folders are always assigned a larger key.
+func (db *DB) MaxObjectID() (int64, error) {
+	var id int64
+	err := db.conn.QueryRow(`SELECT COALESCE(MAX(Z_PK), 0) FROM ZICCLOUDSYNCINGOBJECT`).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read max object id: %w", err)
+	}
+	return id, nil
+}
+
+// FindNoteCreatedAfter returns the newest note with the given title in the
+// given folder whose ID is larger than afterID, or nil if there is none yet
+func (db *DB) FindNoteCreatedAfter(title, folder string, afterID int64) (*Note, error) {
+	query := `
+		SELECT ZICCLOUDSYNCINGOBJECT.Z_PK
+		FROM ZICCLOUDSYNCINGOBJECT
+		LEFT JOIN ZICCLOUDSYNCINGOBJECT as folders ON ZICCLOUDSYNCINGOBJECT.ZFOLDER = folders.Z_PK
+		WHERE ZICCLOUDSYNCINGOBJECT.ZMARKEDFORDELETION = 0
+			AND ZICCLOUDSYNCINGOBJECT.ZTITLE1 = ?
+			AND COALESCE(folders.ZTITLE2, 'Notes') = ?
+			AND ZICCLOUDSYNCINGOBJECT.Z_PK > ?
+		ORDER BY ZICCLOUDSYNCINGOBJECT.Z_PK DESC
+		LIMIT 1
+	`
+
+	var id string
+	err := db.conn.QueryRow(query, title, lastPathComponent(folder), afterID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up new note: %w", err)
+	}
+
+	return db.GetNote(id)
+}
+
+// ModificationStamp returns the raw modification timestamp of a note. Unlike
+// Note.Modified it keeps sub-second precision, so consecutive writes can be
+// told apart.
+func (db *DB) ModificationStamp(id string) (float64, error) {
+	var stamp float64
+	err := db.conn.QueryRow(`
+		SELECT COALESCE(ZMODIFICATIONDATE1, 0)
+		FROM ZICCLOUDSYNCINGOBJECT
+		WHERE Z_PK = ? AND ZMARKEDFORDELETION = 0
+	`, id).Scan(&stamp)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("note not found: %s", id)
+		}
+		return 0, fmt.Errorf("failed to read modification date: %w", err)
+	}
+	return stamp, nil
+}
+
+// NoteExists reports whether a note with the given ID exists and is not deleted
+func (db *DB) NoteExists(id string) (bool, error) {
+	var count int
+	err := db.conn.QueryRow(`
+		SELECT COUNT(*)
+		FROM ZICCLOUDSYNCINGOBJECT
+		WHERE Z_PK = ? AND ZTITLE1 IS NOT NULL AND ZMARKEDFORDELETION = 0
+	`, id).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to check note: %w", err)
+	}
+	return count > 0, nil
+}
+
+// FindFolder resolves a folder path such as "Work/Clients" by following the
+// parent chain from a top-level folder. It returns 0 if the folder does not
+// exist.
+func (db *DB) FindFolder(folderPath string) (int64, error) {
+	var parent int64
+	for _, name := range strings.Split(folderPath, "/") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		// A top-level folder has no parent, or an untitled account root as
+		// parent, the same place NoteFolderPath stops climbing
+		var id int64
+		err := db.conn.QueryRow(`
+			SELECT f.Z_PK
+			FROM ZICCLOUDSYNCINGOBJECT f
+			WHERE f.ZTITLE2 = ?
+				AND f.ZMARKEDFORDELETION = 0
+				AND CASE WHEN ? = 0
+					THEN f.ZPARENT IS NULL OR NOT EXISTS (
+						SELECT 1 FROM ZICCLOUDSYNCINGOBJECT p
+						WHERE p.Z_PK = f.ZPARENT AND p.ZTITLE2 IS NOT NULL
+					)
+					ELSE f.ZPARENT = ?
+				END
+			ORDER BY f.Z_PK
+			LIMIT 1
+		`, name, parent, parent).Scan(&id)
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to look up folder: %w", err)
+		}
+		parent = id
+	}
+	return parent, nil
+}
+
+// lastPathComponent returns the folder name a note reports for a nested path
+func lastPathComponent(folderPath string) string {
+	parts := strings.Split(strings.Trim(folderPath, "/ "), "/")
+	return strings.TrimSpace(parts[len(parts)-1])
+}
@@ -0,0 +1,113 @@
+package dedupe
+
+import (
+	"slices"
+	"strings"
+)
+
+// DiffOp is the kind of a diff line
+type DiffOp byte
+
+const (
+	DiffEqual  DiffOp = ' '
+	DiffDelete DiffOp = '-'
+	DiffInsert DiffOp = '+'
+)
+
+// DiffLine is one line of a line-based diff
+type DiffLine struct {
+	Op   DiffOp
+	Text string
+}
+
+// Diff returns a line-based diff turning a into b, computed from the longest
+// common subsequence of lines. Hirschberg's algorithm keeps memory linear in
+// the number of lines.
+func Diff(a, b string) []DiffLine {
+	x := strings.Split(a, "\n")
+	y := strings.Split(b, "\n")
+	return diffLines(x, y, nil)
+}
+
+// diffLines appends the diff of x and y to out, splitting x in half and y
+// where the two halves' common subsequences meet
+func diffLines(x, y []string, out []DiffLine) []DiffLine {
+	// Common leading and trailing lines need no search
+	prefix := 0
+	for prefix < len(x) && prefix < len(y) && x[prefix] == y[prefix] {
+		prefix++
+	}
+	out = appendLines(out, DiffEqual, x[:prefix])
+	x, y = x[prefix:], y[prefix:]
+	suffix := 0
+	for suffix < len(x) && suffix < len(y) && x[len(x)-1-suffix] == y[len(y)-1-suffix] {
+		suffix++
+	}
+	tail := x[len(x)-suffix:]
+	x, y = x[:len(x)-suffix], y[:len(y)-suffix]
+
+	switch {
+	case len(x) == 0:
+		out = appendLines(out, DiffInsert, y)
+	case len(y) == 0:
+		out = appendLines(out, DiffDelete, x)
+	case len(x) == 1:
+		k := slices.Index(y, x[0])
+		if k < 0 {
+			out = append(out, DiffLine{DiffDelete, x[0]})
+			out = appendLines(out, DiffInsert, y)
+			break
+		}
+		out = appendLines(out, DiffInsert, y[:k])
+		out = append(out, DiffLine{DiffEqual, x[0]})
+		out = appendLines(out, DiffInsert, y[k+1:])
+	default:
+		mid := len(x) / 2
+		forward := lcsLengths(x[:mid], y)
+		backward := lcsLengths(reversed(x[mid:]), reversed(y))
+		split, best := 0, -1
+		for j := 0; j <= len(y); j++ {
+			if n := forward[j] + backward[len(y)-j]; n > best {
+				split, best = j, n
+			}
+		}
+		out = diffLines(x[:mid], y[:split], out)
+		out = diffLines(x[mid:], y[split:], out)
+	}
+
+	return appendLines(out, DiffEqual, tail)
+}
+
+func appendLines(out []DiffLine, op DiffOp, lines []string) []DiffLine {
+	for _, line := range lines {
+		out = append(out, DiffLine{op, line})
+	}
+	return out
+}
+
+// lcsLengths returns, for each j, the length of the longest common
+// subsequence of x and y[:j], using a single row
+func lcsLengths(x, y []string) []int {
+	row := make([]int, len(y)+1)
+	for _, xl := range x {
+		diag := 0
+		for j, yl := range y {
+			up := row[j+1]
+			if xl == yl {
+				row[j+1] = diag + 1
+			} else if row[j] > up {
+				row[j+1] = row[j]
+			}
+			diag = up
+		}
+	}
+	return row
+}
+
+func reversed(lines []string) []string {
+	r := make([]string, len(lines))
+	for i, line := range lines {
+		r[len(lines)-1-i] = line
+	}
+	return r
+}
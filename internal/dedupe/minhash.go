@@ -0,0 +1,227 @@
+package dedupe
+
+import (
+	"hash/fnv"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	// shingleSize is the number of consecutive words in a shingle
+	shingleSize = 3
+	// numHashes is the MinHash signature length
+	numHashes = 128
+	// bands and rows split the signature for locality-sensitive hashing;
+	// bands*rows must equal numHashes
+	bands = 32
+	rows  = 4
+	// maxBucketPairs is the largest bucket whose members are all paired.
+	// Bigger buckets, usually many copies of the same boilerplate, are
+	// chained member to member instead: k-1 candidates rather than k².
+	maxBucketPairs = 50
+)
+
+// Document is a piece of text to compare, identified by ID
+type Document struct {
+	ID   string
+	Text string
+}
+
+// Pair is two documents and the Jaccard similarity of their shingles
+type Pair struct {
+	A, B       string
+	Similarity float64
+}
+
+// Group is a set of mutually connected similar documents
+type Group struct {
+	IDs   []string
+	Pairs []Pair
+}
+
+// FindSimilar groups documents whose shingle sets have a Jaccard similarity of
+// at least threshold. Candidate pairs are found with MinHash and LSH banding,
+// then scored exactly, so the cost stays close to linear in the number of
+// documents.
+func FindSimilar(docs []Document, threshold float64) []Group {
+	shingles := make([]map[uint64]bool, len(docs))
+	signatures := make([][numHashes]uint64, len(docs))
+	for i, doc := range docs {
+		shingles[i] = Shingles(doc.Text)
+		signatures[i] = signature(shingles[i])
+	}
+
+	// Documents that agree on every row of at least one band are candidates
+	candidates := make(map[[2]int]bool)
+	for b := 0; b < bands; b++ {
+		buckets := make(map[uint64][]int)
+		for i := range docs {
+			if len(shingles[i]) == 0 {
+				continue
+			}
+			h := fnv.New64a()
+			for r := 0; r < rows; r++ {
+				v := signatures[i][b*rows+r]
+				for s := 0; s < 8; s++ {
+					h.Write([]byte{byte(v >> (8 * s))})
+				}
+			}
+			key := h.Sum64()
+			buckets[key] = append(buckets[key], i)
+		}
+		for _, members := range buckets {
+			if len(members) > maxBucketPairs {
+				for x := 1; x < len(members); x++ {
+					candidates[[2]int{members[x-1], members[x]}] = true
+				}
+				continue
+			}
+			for x := 0; x < len(members); x++ {
+				for y := x + 1; y < len(members); y++ {
+					candidates[[2]int{members[x], members[y]}] = true
+				}
+			}
+		}
+	}
+
+	parent := make([]int, len(docs))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	var pairs []Pair
+	for c := range candidates {
+		sim := Jaccard(shingles[c[0]], shingles[c[1]])
+		if sim < threshold {
+			continue
+		}
+		pairs = append(pairs, Pair{A: docs[c[0]].ID, B: docs[c[1]].ID, Similarity: sim})
+		parent[find(c[0])] = find(c[1])
+	}
+
+	pairRoots := make(map[int][]Pair)
+	index := make(map[string]int, len(docs))
+	for i, doc := range docs {
+		index[doc.ID] = i
+	}
+	for _, p := range pairs {
+		root := find(index[p.A])
+		pairRoots[root] = append(pairRoots[root], p)
+	}
+
+	var groups []Group
+	members := make(map[int][]string)
+	for i, doc := range docs {
+		if _, ok := pairRoots[find(i)]; ok {
+			members[find(i)] = append(members[find(i)], doc.ID)
+		}
+	}
+	for root, ids := range members {
+		groupPairs := pairRoots[root]
+		sort.Slice(groupPairs, func(i, j int) bool {
+			return groupPairs[i].Similarity > groupPairs[j].Similarity
+		})
+		groups = append(groups, Group{IDs: ids, Pairs: groupPairs})
+	}
+
+	// Largest and most similar groups first
+	sort.Slice(groups, func(i, j int) bool {
+		if len(groups[i].IDs) != len(groups[j].IDs) {
+			return len(groups[i].IDs) > len(groups[j].IDs)
+		}
+		return groups[i].Pairs[0].Similarity > groups[j].Pairs[0].Similarity
+	})
+
+	return groups
+}
+
+// Shingles returns the set of hashed word shingles of text. Case and
+// punctuation are ignored. Texts shorter than a shingle yield a single
+// shingle of all their words.
+func Shingles(text string) map[uint64]bool {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	set := make(map[uint64]bool)
+	if len(words) == 0 {
+		return set
+	}
+	if len(words) < shingleSize {
+		set[hashString(strings.Join(words, " "))] = true
+		return set
+	}
+	for i := 0; i+shingleSize <= len(words); i++ {
+		set[hashString(strings.Join(words[i:i+shingleSize], " "))] = true
+	}
+	return set
+}
+
+// Jaccard returns the Jaccard similarity of two shingle sets
+func Jaccard(a, b map[uint64]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 0
+	}
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	shared := 0
+	for s := range a {
+		if b[s] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// signature computes the MinHash signature of a shingle set. Each of the
+// hash functions is the shingle hash mixed with a different seed.
+func signature(shingles map[uint64]bool) [numHashes]uint64 {
+	var sig [numHashes]uint64
+	for i := range sig {
+		sig[i] = ^uint64(0)
+	}
+	for s := range shingles {
+		for i := range sig {
+			if h := mix(s ^ seeds[i]); h < sig[i] {
+				sig[i] = h
+			}
+		}
+	}
+	return sig
+}
+
+// seeds holds one fixed seed per hash function
+var seeds = func() [numHashes]uint64 {
+	var s [numHashes]uint64
+	x := uint64(0x9e3779b97f4a7c15)
+	for i := range s {
+		x = mix(x + uint64(i))
+		s[i] = x
+	}
+	return s
+}()
+
+// mix is the splitmix64 finalizer
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+func hashString(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
@@ -0,0 +1,171 @@
+// Package fuzzy ranks strings against a short query, for finding notes by
+// a partial or misspelled title. Case and accents are ignored.
+//
+// Scores fall into tiers, best first: an exact match (1), the query as a
+// prefix or substring (0.8 to 1), the query's characters in order with gaps
+// (0.5 to 0.8), and words within a small edit distance of the query (below
+// 0.5).
+package fuzzy
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/fishfisher/apple-notes/internal/textnorm"
+)
+
+// minSimilarity is the lowest edit similarity (1 - distance / length) that
+// counts as a typo rather than a different word
+const minSimilarity = 0.6
+
+// Match is a ranked string
+type Match struct {
+	// Index is the position of the string in the ranked slice
+	Index int
+	Score float64
+}
+
+// Score rates how well target matches query, from 0 (no match) to 1
+// (equal)
+func Score(query, target string) float64 {
+	q := []rune(textnorm.Fold(strings.TrimSpace(query)))
+	t := []rune(textnorm.Fold(strings.TrimSpace(target)))
+	if len(q) == 0 || len(t) == 0 {
+		return 0
+	}
+
+	qs, ts := string(q), string(t)
+	ratio := float64(len(q)) / float64(len(t))
+	switch {
+	case qs == ts:
+		return 1
+	case strings.HasPrefix(ts, qs):
+		return 0.9 + 0.09*ratio
+	case strings.Contains(ts, qs):
+		return 0.8 + 0.09*ratio
+	}
+
+	if span := subsequenceSpan(q, t); span > 0 {
+		return 0.5 + 0.29*float64(len(q))/float64(span)
+	}
+
+	if similarity := wordSimilarity(qs, ts); similarity >= minSimilarity {
+		return 0.49 * similarity
+	}
+	return 0
+}
+
+// Rank scores every target and returns those that match, best first.
+// Equal scores keep the order of targets.
+func Rank(query string, targets []string) []Match {
+	var matches []Match
+	for i, target := range targets {
+		if score := Score(query, target); score > 0 {
+			matches = append(matches, Match{Index: i, Score: score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+	return matches
+}
+
+// Top returns the leading matches in the same tier as the best one, so a
+// substring match is not made ambiguous by loose typo matches
+func Top(matches []Match) []Match {
+	if len(matches) == 0 {
+		return nil
+	}
+	best := tier(matches[0].Score)
+	n := 1
+	for n < len(matches) && tier(matches[n].Score) == best {
+		n++
+	}
+	return matches[:n]
+}
+
+// Loose reports whether score comes from the subsequence or typo tier,
+// where the query does not appear as written in the target
+func Loose(score float64) bool {
+	return tier(score) < 2
+}
+
+func tier(score float64) int {
+	switch {
+	case score >= 1:
+		return 3
+	case score >= 0.8:
+		return 2
+	case score >= 0.5:
+		return 1
+	}
+	return 0
+}
+
+// subsequenceSpan returns the length of the stretch of t that contains the
+// runes of q in order, found greedily, or 0 when q is not a subsequence
+func subsequenceSpan(q, t []rune) int {
+	start, j := -1, 0
+	for i, r := range t {
+		if r != q[j] {
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+		j++
+		if j == len(q) {
+			return i - start + 1
+		}
+	}
+	return 0
+}
+
+// wordSimilarity compares q with every run of as many words in t as q has,
+// and with t as a whole, returning the best edit similarity
+func wordSimilarity(q, t string) float64 {
+	best := similarity(q, t)
+	qWords := len(strings.Fields(q))
+	tWords := strings.Fields(t)
+	for i := 0; i+qWords <= len(tWords); i++ {
+		if s := similarity(q, strings.Join(tWords[i:i+qWords], " ")); s > best {
+			best = s
+		}
+	}
+	return best
+}
+
+// similarity is 1 minus the edit distance relative to the longer string
+func similarity(a, b string) float64 {
+	ar, br := []rune(a), []rune(b)
+	longest := len(ar)
+	if len(br) > longest {
+		longest = len(br)
+	}
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ar, br))/float64(longest)
+}
+
+// levenshtein returns the number of single-rune insertions, deletions and
+// substitutions that turn a into b
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,118 @@
+// Package grep searches note bodies line by line with a regular expression.
+// Notes are decoded and searched concurrently, since decoding the gzipped
+// note data dominates the cost.
+package grep
+
+import (
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/fishfisher/apple-notes/internal/db"
+)
+
+// Line is a line of a note body. Context lines have Match set to false.
+type Line struct {
+	Number int
+	Text   string
+	Match  bool
+	// Ranges are the byte offsets of the matches in Text
+	Ranges [][]int
+}
+
+// Result holds the matching lines of a note
+type Result struct {
+	Note db.Note
+	// Lines are the matching lines and their context, in order
+	Lines []Line
+	// Matches is the number of matching lines
+	Matches int
+}
+
+// Options control a search
+type Options struct {
+	// Context is the number of lines shown before and after each match
+	Context int
+	// Workers is the number of notes searched at once; 0 uses one per CPU
+	Workers int
+}
+
+// Search decodes notes and returns the ones with at least one matching
+// line, in the order given. Bodies are not kept in the results.
+func Search(notes []db.NoteData, re *regexp.Regexp, opts Options) []Result {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	results := make([]Result, len(notes))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				note := notes[i].Decode()
+				lines, matches := Lines(note.Body, re, opts.Context)
+				note.Body = ""
+				results[i] = Result{Note: note, Lines: lines, Matches: matches}
+			}
+		}()
+	}
+	for i := range notes {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	var found []Result
+	for _, result := range results {
+		if result.Matches > 0 {
+			found = append(found, result)
+		}
+	}
+	return found
+}
+
+// Lines returns the lines of text matching re with up to context lines
+// around each, and the number of matching lines
+func Lines(text string, re *regexp.Regexp, context int) ([]Line, int) {
+	all := strings.Split(text, "\n")
+
+	var ranges [][][]int
+	var matched []int
+	for i, line := range all {
+		if r := re.FindAllStringIndex(line, -1); r != nil {
+			if ranges == nil {
+				ranges = make([][][]int, len(all))
+			}
+			ranges[i] = r
+			matched = append(matched, i)
+		}
+	}
+	if len(matched) == 0 {
+		return nil, 0
+	}
+
+	var lines []Line
+	next := 0 // first line not yet added
+	for _, i := range matched {
+		from := i - context
+		if from < next {
+			from = next
+		}
+		to := i + context
+		if to >= len(all) {
+			to = len(all) - 1
+		}
+		for j := from; j <= to; j++ {
+			lines = append(lines, Line{Number: j + 1, Text: all[j], Match: ranges[j] != nil, Ranges: ranges[j]})
+		}
+		if to+1 > next {
+			next = to + 1
+		}
+	}
+	return lines, len(matched)
+}
@@ -0,0 +1,424 @@
+// Package index maintains an optional full-text search index of note
+// titles, bodies and attachment text in a sidecar SQLite database using
+// FTS5. FTS5 is only compiled into go-sqlite3 with the sqlite_fts5 build
+// tag, so its availability is checked at runtime.
+package index
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fishfisher/apple-notes/internal/db"
+	"github.com/fishfisher/apple-notes/internal/xdg"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+var (
+	// ErrUnavailable means this binary was built without FTS5
+	ErrUnavailable = errors.New("full-text search is not available in this build (rebuild with -tags sqlite_fts5)")
+	// ErrNotBuilt means the index has not been created yet
+	ErrNotBuilt = errors.New("search index not built (run 'apple-notes index build')")
+)
+
+const schema = `
+	CREATE VIRTUAL TABLE IF NOT EXISTS notes USING fts5(
+		id UNINDEXED, title, body, attachments,
+		tokenize = 'unicode61 remove_diacritics 2'
+	);
+	CREATE TABLE IF NOT EXISTS stamps (id TEXT PRIMARY KEY, modified INTEGER NOT NULL);
+	CREATE TABLE IF NOT EXISTS meta (key TEXT PRIMARY KEY, value TEXT NOT NULL);
+`
+
+// Index is an open search index
+type Index struct {
+	conn *sql.DB
+}
+
+// Status describes the state of the index
+type Status struct {
+	Notes   int
+	Updated time.Time
+}
+
+// Changes counts the notes touched by an update
+type Changes struct {
+	Added   int
+	Updated int
+	Removed int
+}
+
+// Hit is a ranked search result. Lower scores rank higher.
+type Hit struct {
+	ID      string
+	Score   float64
+	Snippet string
+}
+
+// Path returns the index location in the cache directory
+func Path() (string, error) {
+	dir, err := xdg.CacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "index.db"), nil
+}
+
+// Available reports whether FTS5 is compiled in
+func Available() bool {
+	conn, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		return false
+	}
+	defer conn.Close()
+	_, err = conn.Exec("CREATE VIRTUAL TABLE probe USING fts5(x)")
+	return err == nil
+}
+
+// Exists reports whether an index has been built at path
+func Exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// Open opens the index at path, creating it if needed
+func Open(path string) (*Index, error) {
+	if !Available() {
+		return nil, ErrUnavailable
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open search index: %w", err)
+	}
+	if _, err := conn.Exec(schema); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create search index: %w", err)
+	}
+	return &Index{conn: conn}, nil
+}
+
+// OpenExisting opens the index at path, returning ErrNotBuilt if there is none
+func OpenExisting(path string) (*Index, error) {
+	if !Exists(path) {
+		if !Available() {
+			return nil, ErrUnavailable
+		}
+		return nil, ErrNotBuilt
+	}
+	return Open(path)
+}
+
+// Close closes the index
+func (ix *Index) Close() error {
+	return ix.conn.Close()
+}
+
+// Build replaces the index contents with every note in the database
+func (ix *Index) Build(database *db.DB) (Changes, error) {
+	return ix.update(database, true)
+}
+
+// Update indexes notes that were added or modified since they were last
+// indexed and removes deleted notes
+func (ix *Index) Update(database *db.DB) (Changes, error) {
+	return ix.update(database, false)
+}
+
+// update applies the changes since the last update in one transaction. With
+// rebuild, the index is cleared in the same transaction and every note is
+// indexed again, so a failed rebuild leaves the old index in place.
+func (ix *Index) update(database *db.DB, rebuild bool) (Changes, error) {
+	var changes Changes
+
+	notes, err := database.ListNotes("")
+	if err != nil {
+		return changes, err
+	}
+	stamps := map[string]int64{}
+	if !rebuild {
+		if stamps, err = ix.stamps(); err != nil {
+			return changes, err
+		}
+	}
+
+	var changed []db.Note
+	current := make(map[string]bool, len(notes))
+	for _, note := range notes {
+		current[note.ID] = true
+		stamp, ok := stamps[note.ID]
+		switch {
+		case !ok:
+			changes.Added++
+		case stamp != note.Modified.Unix():
+			changes.Updated++
+		default:
+			continue
+		}
+		changed = append(changed, note)
+	}
+
+	// Decoding every body in one query is faster when most notes changed
+	if len(changed) > len(notes)/2 {
+		if changed, err = changedBodies(database, changed); err != nil {
+			return changes, err
+		}
+	} else {
+		for i := range changed {
+			body, err := database.GetNoteText(changed[i].ID)
+			if err != nil {
+				body = changed[i].Snippet
+			}
+			changed[i].Body = body
+		}
+	}
+
+	var attachments map[string]string
+	if len(changed) > 0 {
+		if attachments, err = database.ListAttachmentText(); err != nil {
+			return changes, err
+		}
+	}
+
+	tx, err := ix.conn.Begin()
+	if err != nil {
+		return changes, fmt.Errorf("failed to update search index: %w", err)
+	}
+	defer tx.Rollback()
+
+	if rebuild {
+		if _, err := tx.Exec("DELETE FROM notes; DELETE FROM stamps"); err != nil {
+			return changes, fmt.Errorf("failed to clear search index: %w", err)
+		}
+	}
+	for id := range stamps {
+		if current[id] {
+			continue
+		}
+		if err := removeNote(tx, id); err != nil {
+			return changes, err
+		}
+		changes.Removed++
+	}
+	for _, note := range changed {
+		if err := removeNote(tx, note.ID); err != nil {
+			return changes, err
+		}
+		if _, err := tx.Exec("INSERT INTO notes (id, title, body, attachments) VALUES (?, ?, ?, ?)",
+			note.ID, note.Title, note.Body, attachments[note.ID]); err != nil {
+			return changes, fmt.Errorf("failed to index note %s: %w", note.ID, err)
+		}
+		if _, err := tx.Exec("INSERT INTO stamps (id, modified) VALUES (?, ?)", note.ID, note.Modified.Unix()); err != nil {
+			return changes, fmt.Errorf("failed to index note %s: %w", note.ID, err)
+		}
+	}
+	if _, err := tx.Exec("INSERT OR REPLACE INTO meta (key, value) VALUES ('updated', ?)", strconv.FormatInt(time.Now().Unix(), 10)); err != nil {
+		return changes, fmt.Errorf("failed to update search index: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return changes, fmt.Errorf("failed to update search index: %w", err)
+	}
+	return changes, nil
+}
+
+// changedBodies returns the changed notes with decoded bodies
+func changedBodies(database *db.DB, changed []db.Note) ([]db.Note, error) {
+	notes, err := database.ListNoteBodies("")
+	if err != nil {
+		return nil, err
+	}
+	wanted := make(map[string]bool, len(changed))
+	for _, note := range changed {
+		wanted[note.ID] = true
+	}
+	var result []db.Note
+	for _, note := range notes {
+		if wanted[note.ID] {
+			result = append(result, note)
+		}
+	}
+	return result, nil
+}
+
+func removeNote(tx *sql.Tx, id string) error {
+	if _, err := tx.Exec("DELETE FROM notes WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to remove note %s from search index: %w", id, err)
+	}
+	if _, err := tx.Exec("DELETE FROM stamps WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to remove note %s from search index: %w", id, err)
+	}
+	return nil
+}
+
+// stamps returns the modification time (Unix seconds) of each indexed note
+func (ix *Index) stamps() (map[string]int64, error) {
+	rows, err := ix.conn.Query("SELECT id, modified FROM stamps")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read search index: %w", err)
+	}
+	defer rows.Close()
+
+	stamps := make(map[string]int64)
+	for rows.Next() {
+		var id string
+		var modified int64
+		if err := rows.Scan(&id, &modified); err != nil {
+			return nil, fmt.Errorf("failed to read search index: %w", err)
+		}
+		stamps[id] = modified
+	}
+	return stamps, nil
+}
+
+// Stale returns the number of notes added, modified or deleted since they
+// were indexed
+func (ix *Index) Stale(database *db.DB) (int, error) {
+	notes, err := database.ListNotes("")
+	if err != nil {
+		return 0, err
+	}
+	stamps, err := ix.stamps()
+	if err != nil {
+		return 0, err
+	}
+
+	stale := 0
+	for _, note := range notes {
+		if stamp, ok := stamps[note.ID]; !ok || stamp != note.Modified.Unix() {
+			stale++
+		}
+		delete(stamps, note.ID)
+	}
+	return stale + len(stamps), nil
+}
+
+// Status returns the number of indexed notes and the time of the last update
+func (ix *Index) Status() (Status, error) {
+	var status Status
+	if err := ix.conn.QueryRow("SELECT COUNT(*) FROM stamps").Scan(&status.Notes); err != nil {
+		return status, fmt.Errorf("failed to read search index: %w", err)
+	}
+
+	var updated string
+	err := ix.conn.QueryRow("SELECT value FROM meta WHERE key = 'updated'").Scan(&updated)
+	if err != nil && err != sql.ErrNoRows {
+		return status, fmt.Errorf("failed to read search index: %w", err)
+	}
+	if seconds, err := strconv.ParseInt(updated, 10, 64); err == nil {
+		status.Updated = time.Unix(seconds, 0)
+	}
+	return status, nil
+}
+
+// Search returns one page of notes matching text, best matches first, and
+// the total number of matches. Snippets mark matched terms with open and
+// close.
+func (ix *Index) Search(text string, limit, offset int, open, close string) ([]Hit, int, error) {
+	match := MatchQuery(text)
+	if match == "" {
+		return nil, 0, fmt.Errorf("empty search")
+	}
+
+	var total int
+	if err := ix.conn.QueryRow("SELECT COUNT(*) FROM notes WHERE notes MATCH ?", match).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to search index: %w", err)
+	}
+
+	if limit <= 0 {
+		limit = -1
+	}
+	rows, err := ix.conn.Query(`
+		SELECT id, bm25(notes, 0.0, 10.0, 1.0, 0.5) as score, snippet(notes, -1, ?, ?, '…', 16)
+		FROM notes
+		WHERE notes MATCH ?
+		ORDER BY score
+		LIMIT ? OFFSET ?
+	`, open, close, match, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search index: %w", err)
+	}
+	defer rows.Close()
+
+	var hits []Hit
+	for rows.Next() {
+		var hit Hit
+		if err := rows.Scan(&hit.ID, &hit.Score, &hit.Snippet); err != nil {
+			return nil, 0, fmt.Errorf("failed to read search results: %w", err)
+		}
+		hit.Snippet = strings.Join(strings.Fields(hit.Snippet), " ")
+		hits = append(hits, hit)
+	}
+	return hits, total, nil
+}
+
+// MatchQuery turns search text into an FTS5 query. Words and "quoted
+// phrases" must all match; OR and NOT are kept as operators and a trailing
+// * matches a prefix. Everything else is quoted so punctuation cannot
+// break the query.
+func MatchQuery(text string) string {
+	var terms []string
+	for _, term := range splitTerms(text) {
+		switch {
+		case term == "OR" || term == "NOT" || term == "AND":
+			terms = append(terms, term)
+		case strings.HasSuffix(term, "*") && len(term) > 1:
+			terms = append(terms, quote(strings.TrimSuffix(term, "*"))+"*")
+		default:
+			terms = append(terms, quote(term))
+		}
+	}
+
+	// Operators cannot start or end the query
+	for len(terms) > 0 && isOperator(terms[0]) {
+		terms = terms[1:]
+	}
+	for len(terms) > 0 && isOperator(terms[len(terms)-1]) {
+		terms = terms[:len(terms)-1]
+	}
+	return strings.Join(terms, " ")
+}
+
+// splitTerms splits text on spaces, keeping "quoted phrases" together
+func splitTerms(text string) []string {
+	var terms []string
+	var current strings.Builder
+	quoted := false
+	for _, r := range text {
+		switch {
+		case r == '"':
+			if quoted && current.Len() > 0 {
+				terms = append(terms, current.String())
+				current.Reset()
+			}
+			quoted = !quoted
+		case !quoted && (r == ' ' || r == '\t' || r == '\n'):
+			if current.Len() > 0 {
+				terms = append(terms, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if current.Len() > 0 {
+		terms = append(terms, current.String())
+	}
+	return terms
+}
+
+func quote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+func isOperator(term string) bool {
+	return term == "OR" || term == "NOT" || term == "AND"
+}
@@ -0,0 +1,242 @@
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fishfisher/apple-notes/internal/xdg"
+)
+
+// Action is the kind of change an entry records
+type Action string
+
+const (
+	ActionCreate Action = "create" // note was created; undo deletes it
+	ActionUpdate Action = "update" // title/body changed; undo restores them
+	ActionMove   Action = "move"   // note changed folder; undo moves it back
+	ActionDelete Action = "delete" // note was deleted; undo recreates it
+	ActionUndo   Action = "undo"   // an earlier entry was reversed
+)
+
+// Entry is one journaled write. Title, Body and Folder hold the state of the
+// note before the write, so it can be reversed. Folder is the full folder
+// path, e.g. "Work/Clients".
+type Entry struct {
+	Seq     int       `json:"seq"`
+	Time    time.Time `json:"time"`
+	Command string    `json:"command"`
+	Action  Action    `json:"action"`
+	NoteID  string    `json:"note_id,omitempty"`
+	Title   string    `json:"title,omitempty"`
+	Body    string    `json:"body,omitempty"`
+	Folder  string    `json:"folder,omitempty"`
+	Undoes  int       `json:"undoes,omitempty"`
+}
+
+// Path returns the location of the journal file
+func Path() (string, error) {
+	dir, err := xdg.StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "journal.jsonl"), nil
+}
+
+// Load reads all entries in the order they were written
+func Load() ([]Entry, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return []Entry{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse journal entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+
+	return entries, nil
+}
+
+// Retention limits: entries older than MaxAge are dropped, and at most
+// MaxEntries are kept. The journal is pruned every pruneInterval entries.
+const (
+	MaxEntries    = 1000
+	MaxAge        = 30 * 24 * time.Hour
+	pruneInterval = 100
+)
+
+// seqPath returns the file holding the last sequence number, so appending
+// does not need to read the journal
+func seqPath(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".seq"
+}
+
+// lastSeq returns the sequence number of the newest entry. Journals written
+// before the sequence file existed are read once to find it.
+func lastSeq(path string) (int, error) {
+	data, err := os.ReadFile(seqPath(path))
+	if err == nil {
+		if seq, err := strconv.Atoi(strings.TrimSpace(string(data))); err == nil {
+			return seq, nil
+		}
+	} else if !os.IsNotExist(err) {
+		return 0, fmt.Errorf("failed to read journal sequence: %w", err)
+	}
+
+	existing, err := Load()
+	if err != nil {
+		return 0, err
+	}
+	if len(existing) == 0 {
+		return 0, nil
+	}
+	return existing[len(existing)-1].Seq, nil
+}
+
+// Append assigns entries the next sequence numbers and writes them to the journal
+func Append(entries ...Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create journal directory: %w", err)
+	}
+
+	first, err := lastSeq(path)
+	if err != nil {
+		return err
+	}
+	seq := first
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open journal: %w", err)
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	for _, entry := range entries {
+		seq++
+		entry.Seq = seq
+		if entry.Time.IsZero() {
+			entry.Time = time.Now()
+		}
+		if err := enc.Encode(entry); err != nil {
+			return fmt.Errorf("failed to write journal entry: %w", err)
+		}
+	}
+
+	if err := os.WriteFile(seqPath(path), []byte(strconv.Itoa(seq)+"\n"), 0600); err != nil {
+		return fmt.Errorf("failed to write journal sequence: %w", err)
+	}
+
+	if first/pruneInterval != seq/pruneInterval {
+		return prune(path, time.Now())
+	}
+	return nil
+}
+
+// prune rewrites the journal without the entries outside the retention
+// limits
+func prune(path string, now time.Time) error {
+	entries, err := Load()
+	if err != nil {
+		return err
+	}
+
+	cutoff := now.Add(-MaxAge)
+	var kept []Entry
+	for _, entry := range entries {
+		if !entry.Time.Before(cutoff) {
+			kept = append(kept, entry)
+		}
+	}
+	if len(kept) > MaxEntries {
+		kept = kept[len(kept)-MaxEntries:]
+	}
+	if len(kept) == len(entries) {
+		return nil
+	}
+
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to prune journal: %w", err)
+	}
+	enc := json.NewEncoder(f)
+	for _, entry := range kept {
+		if err := enc.Encode(entry); err != nil {
+			f.Close()
+			return fmt.Errorf("failed to prune journal: %w", err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to prune journal: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to prune journal: %w", err)
+	}
+	return nil
+}
+
+// Undoable returns the entries that have not been undone yet, newest first
+func Undoable(entries []Entry) []Entry {
+	undone := make(map[int]bool)
+	for _, entry := range entries {
+		if entry.Action == ActionUndo {
+			undone[entry.Undoes] = true
+		}
+	}
+
+	var pending []Entry
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		if entry.Action == ActionUndo || undone[entry.Seq] {
+			continue
+		}
+		pending = append(pending, entry)
+	}
+	return pending
+}
+
+// IsUndone reports whether the entry with the given sequence number was undone
+func IsUndone(entries []Entry, seq int) bool {
+	for _, entry := range entries {
+		if entry.Action == ActionUndo && entry.Undoes == seq {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,214 @@
+package markup
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// Notes stores bodies as HTML. These helpers turn captured text into the
+// small subset of HTML that Notes renders: divs, headings, lists, pre blocks
+// and basic inline styles.
+
+var (
+	headingRe   = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	bulletRe    = regexp.MustCompile(`^\s*[-*+]\s+(.*)$`)
+	orderedRe   = regexp.MustCompile(`^\s*\d+[.)]\s+(.*)$`)
+	quoteRe     = regexp.MustCompile(`^>\s?(.*)$`)
+	fenceRe     = regexp.MustCompile("^\\s*(```|~~~)")
+	linkRe      = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	boldRe      = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	italicRe    = regexp.MustCompile(`(^|[^*\w])\*([^*\s][^*]*)\*|(^|\W)_([^_\s][^_]*)_`)
+	codeSpanRe  = regexp.MustCompile("`([^`]+)`")
+	inlineMdRe  = regexp.MustCompile("\\*\\*[^*]+\\*\\*|`[^`]+`|\\[[^\\]]+\\]\\([^)\\s]+\\)")
+	firstWordRe = regexp.MustCompile(`\S`)
+	hashtagRe   = regexp.MustCompile(`(^|\s|&nbsp;)#[^\s&<]*[^\s&<.,!?;:)]`)
+	htmlTagRe   = regexp.MustCompile(`<[^>]*>`)
+	emptyDivRe  = regexp.MustCompile(`<div>(\s|&nbsp;)*</div>`)
+)
+
+// IsMarkdown guesses whether text is Markdown. Headings and code fences are
+// decisive; otherwise at least two lines must use list, quote or inline
+// Markdown syntax.
+func IsMarkdown(text string) bool {
+	signals := 0
+	for _, line := range strings.Split(text, "\n") {
+		switch {
+		case headingRe.MatchString(line), fenceRe.MatchString(line):
+			return true
+		case bulletRe.MatchString(line), orderedRe.MatchString(line), quoteRe.MatchString(line), inlineMdRe.MatchString(line):
+			signals++
+		}
+		if signals >= 2 {
+			return true
+		}
+	}
+	return false
+}
+
+// Title derives a note title from text: the first "# heading" if there is
+// one, otherwise the first non-empty line. Titles are cut to maxRunes.
+func Title(text string, maxRunes int) string {
+	lines := strings.Split(text, "\n")
+	title := ""
+	for _, line := range lines {
+		if m := headingRe.FindStringSubmatch(line); m != nil && len(m[1]) == 1 {
+			title = m[2]
+			break
+		}
+	}
+	if title == "" {
+		for _, line := range lines {
+			if firstWordRe.MatchString(line) {
+				title = strings.TrimSpace(line)
+				if m := headingRe.FindStringSubmatch(title); m != nil {
+					title = m[2]
+				}
+				break
+			}
+		}
+	}
+
+	runes := []rune(title)
+	if maxRunes > 0 && len(runes) > maxRunes {
+		title = strings.TrimSpace(string(runes[:maxRunes-1])) + "…"
+	}
+	return title
+}
+
+// TextToHTML converts plain text to HTML, one div per line
+func TextToHTML(text string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		writeDiv(&b, html.EscapeString(line))
+	}
+	return b.String()
+}
+
+// MarkdownToHTML converts common Markdown to HTML: ATX headings, bullet and
+// numbered lists, block quotes, fenced code, bold, italics, code spans and
+// links
+func MarkdownToHTML(text string) string {
+	var b strings.Builder
+	list := "" // "ul" or "ol" while inside a list
+	inFence := false
+
+	closeList := func() {
+		if list != "" {
+			b.WriteString("</" + list + ">")
+			list = ""
+		}
+	}
+
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		if fenceRe.MatchString(line) {
+			closeList()
+			if inFence {
+				b.WriteString("</pre>")
+			} else {
+				b.WriteString("<pre>")
+			}
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			b.WriteString(html.EscapeString(line) + "\n")
+			continue
+		}
+
+		if m := bulletRe.FindStringSubmatch(line); m != nil {
+			openList(&b, &list, "ul")
+			b.WriteString("<li>" + inline(m[1]) + "</li>")
+			continue
+		}
+		if m := orderedRe.FindStringSubmatch(line); m != nil {
+			openList(&b, &list, "ol")
+			b.WriteString("<li>" + inline(m[1]) + "</li>")
+			continue
+		}
+		closeList()
+
+		if m := headingRe.FindStringSubmatch(line); m != nil {
+			level := len(m[1])
+			if level > 3 {
+				writeDiv(&b, "<b>"+inline(m[2])+"</b>")
+			} else {
+				tag := "h" + string(rune('0'+level))
+				b.WriteString("<" + tag + ">" + inline(m[2]) + "</" + tag + ">")
+			}
+			continue
+		}
+		if m := quoteRe.FindStringSubmatch(line); m != nil {
+			b.WriteString("<blockquote>" + inline(m[1]) + "</blockquote>")
+			continue
+		}
+		writeDiv(&b, inline(line))
+	}
+
+	closeList()
+	if inFence {
+		b.WriteString("</pre>")
+	}
+	return b.String()
+}
+
+// openList starts a list of the given kind, closing a different open one
+func openList(b *strings.Builder, list *string, kind string) {
+	if *list == kind {
+		return
+	}
+	if *list != "" {
+		b.WriteString("</" + *list + ">")
+	}
+	b.WriteString("<" + kind + ">")
+	*list = kind
+}
+
+// writeDiv writes one line as a div; empty lines become line breaks
+func writeDiv(b *strings.Builder, content string) {
+	if strings.TrimSpace(content) == "" {
+		b.WriteString("<div><br></div>")
+		return
+	}
+	b.WriteString("<div>" + content + "</div>")
+}
+
+// inline escapes a line and converts inline Markdown
+func inline(line string) string {
+	// Code spans are converted first and protected from further formatting
+	var spans []string
+	line = codeSpanRe.ReplaceAllStringFunc(line, func(m string) string {
+		spans = append(spans, "<tt>"+html.EscapeString(m[1:len(m)-1])+"</tt>")
+		return "\x00" + string(rune(len(spans)-1+'a')) + "\x00"
+	})
+
+	line = html.EscapeString(line)
+	line = linkRe.ReplaceAllString(line, `<a href="$2">$1</a>`)
+	line = boldRe.ReplaceAllString(line, "<b>$1$2</b>")
+	line = italicRe.ReplaceAllString(line, "$1$3<i>$2$4</i>")
+
+	for i, span := range spans {
+		line = strings.Replace(line, "\x00"+string(rune(i+'a'))+"\x00", span, 1)
+	}
+	return line
+}
+
+// StripHashtags removes the hashtags from plain text, as found by
+// db.Hashtags: words starting with # minus trailing punctuation
+func StripHashtags(text string) string {
+	return hashtagRe.ReplaceAllString(text, "$1")
+}
+
+// StripHTMLHashtags removes the hashtags from the text of an HTML body,
+// leaving tags and attributes alone. Divs left empty are dropped.
+func StripHTMLHashtags(body string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range htmlTagRe.FindAllStringIndex(body, -1) {
+		b.WriteString(StripHashtags(body[last:loc[0]]))
+		b.WriteString(body[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	b.WriteString(StripHashtags(body[last:]))
+	return emptyDivRe.ReplaceAllString(b.String(), "")
+}
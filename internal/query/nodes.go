@@ -0,0 +1,287 @@
+package query
+
+import (
+	"strings"
+	"time"
+
+	"github.com/fishfisher/apple-notes/internal/textnorm"
+)
+
+// node is an element of the query AST. sql returns a condition selecting a
+// superset of the notes that match, and whether it is exact.
+type node interface {
+	match(c Candidate) bool
+	sql() (string, []interface{}, bool)
+}
+
+// coreDataEpoch is 2001-01-01 UTC, the epoch of Notes timestamps
+const coreDataEpoch = 978307200
+
+type andNode struct{ left, right node }
+
+func (n andNode) match(c Candidate) bool {
+	return n.left.match(c) && n.right.match(c)
+}
+
+func (n andNode) sql() (string, []interface{}, bool) {
+	left, leftArgs, leftExact := n.left.sql()
+	right, rightArgs, rightExact := n.right.sql()
+	switch {
+	case left == "":
+		return right, rightArgs, leftExact && rightExact
+	case right == "":
+		return left, leftArgs, leftExact && rightExact
+	}
+	return "(" + left + " AND " + right + ")", append(leftArgs, rightArgs...), leftExact && rightExact
+}
+
+type orNode struct{ left, right node }
+
+func (n orNode) match(c Candidate) bool {
+	return n.left.match(c) || n.right.match(c)
+}
+
+func (n orNode) sql() (string, []interface{}, bool) {
+	left, leftArgs, leftExact := n.left.sql()
+	right, rightArgs, rightExact := n.right.sql()
+	if left == "" || right == "" {
+		return "", nil, false
+	}
+	return "(" + left + " OR " + right + ")", append(leftArgs, rightArgs...), leftExact && rightExact
+}
+
+type notNode struct{ operand node }
+
+func (n notNode) match(c Candidate) bool {
+	return !n.operand.match(c)
+}
+
+func (n notNode) sql() (string, []interface{}, bool) {
+	condition, args, exact := n.operand.sql()
+	if !exact {
+		// The negation of a superset is not a superset
+		return "", nil, false
+	}
+	return "NOT " + condition, args, true
+}
+
+// textNode matches a word or phrase in the title or body
+type textNode struct {
+	text   string
+	strict bool
+}
+
+func newTextNode(text string, strict bool) textNode {
+	if strict {
+		return textNode{strings.ToLower(text), true}
+	}
+	return textNode{textnorm.Fold(text), false}
+}
+
+func (n textNode) match(c Candidate) bool {
+	if n.strict {
+		return strings.Contains(c.text, n.text)
+	}
+	return strings.Contains(c.folded, n.text)
+}
+
+func (n textNode) sql() (string, []interface{}, bool) {
+	// Bodies are only available after decoding
+	return "", nil, false
+}
+
+// folderNode matches the note's folder. Notes only know their immediate
+// folder, so nested paths match on their last component.
+type folderNode struct {
+	name   string
+	strict bool
+}
+
+func newFolderNode(path string, strict bool) folderNode {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	name := parts[len(parts)-1]
+	if strict {
+		return folderNode{name, true}
+	}
+	return folderNode{textnorm.Fold(name), false}
+}
+
+func (n folderNode) match(c Candidate) bool {
+	if n.strict {
+		return strings.EqualFold(c.Note.Folder, n.name)
+	}
+	return textnorm.Fold(c.Note.Folder) == n.name
+}
+
+func (n folderNode) sql() (string, []interface{}, bool) {
+	if !n.strict {
+		return "fold(COALESCE(folders.ZTITLE2, 'Notes')) = ?", []interface{}{n.name}, true
+	}
+	// SQLite only folds ASCII case
+	if !isASCII(n.name) {
+		return "", nil, false
+	}
+	return "COALESCE(folders.ZTITLE2, 'Notes') = ? COLLATE NOCASE", []interface{}{n.name}, true
+}
+
+// titleNode matches text in the title
+type titleNode struct {
+	text   string
+	strict bool
+}
+
+func newTitleNode(text string, strict bool) titleNode {
+	if strict {
+		return titleNode{strings.ToLower(text), true}
+	}
+	return titleNode{textnorm.Fold(text), false}
+}
+
+func (n titleNode) match(c Candidate) bool {
+	if n.strict {
+		return strings.Contains(strings.ToLower(c.Note.Title), n.text)
+	}
+	return strings.Contains(textnorm.Fold(c.Note.Title), n.text)
+}
+
+func (n titleNode) sql() (string, []interface{}, bool) {
+	pattern := "%" + escapeLike(n.text) + "%"
+	if !n.strict {
+		return `fold(ZICCLOUDSYNCINGOBJECT.ZTITLE1) LIKE ? ESCAPE '\'`, []interface{}{pattern}, true
+	}
+	if !isASCII(n.text) {
+		return "", nil, false
+	}
+	return `ZICCLOUDSYNCINGOBJECT.ZTITLE1 LIKE ? ESCAPE '\'`, []interface{}{pattern}, true
+}
+
+// tagNode matches a hashtag anywhere in the body
+type tagNode struct {
+	tag    string
+	strict bool
+}
+
+func (n tagNode) match(c Candidate) bool {
+	return c.hasTag(n.tag, n.strict)
+}
+
+func (n tagNode) sql() (string, []interface{}, bool) {
+	return "", nil, false
+}
+
+// dateNode matches a creation or modification date in [from, to)
+type dateNode struct {
+	field    string
+	from, to time.Time
+}
+
+func (n dateNode) match(c Candidate) bool {
+	t := c.Note.Modified
+	if n.field == "created" {
+		t = c.Note.Created
+	}
+	return (n.from.IsZero() || !t.Before(n.from)) && (n.to.IsZero() || t.Before(n.to))
+}
+
+func (n dateNode) sql() (string, []interface{}, bool) {
+	column := "ZICCLOUDSYNCINGOBJECT.ZMODIFICATIONDATE1"
+	if n.field == "created" {
+		column = "ZICCLOUDSYNCINGOBJECT.ZCREATIONDATE"
+	}
+
+	var conditions []string
+	var args []interface{}
+	if !n.from.IsZero() {
+		conditions = append(conditions, column+" >= ?")
+		args = append(args, n.from.Unix()-coreDataEpoch)
+	}
+	if !n.to.IsZero() {
+		conditions = append(conditions, column+" < ?")
+		args = append(args, n.to.Unix()-coreDataEpoch)
+	}
+	return "(" + strings.Join(conditions, " AND ") + ")", args, true
+}
+
+// sizeNode compares the number of characters in the body
+type sizeNode struct {
+	op string
+	n  int
+}
+
+func (n sizeNode) match(c Candidate) bool {
+	switch n.op {
+	case ">":
+		return c.BodyRunes > n.n
+	case ">=":
+		return c.BodyRunes >= n.n
+	case "<":
+		return c.BodyRunes < n.n
+	case "<=":
+		return c.BodyRunes <= n.n
+	}
+	return c.BodyRunes == n.n
+}
+
+func (n sizeNode) sql() (string, []interface{}, bool) {
+	return "", nil, false
+}
+
+// hasAttachmentNode matches notes with images, files or tables
+type hasAttachmentNode struct{}
+
+func (hasAttachmentNode) match(c Candidate) bool {
+	return c.Attributes.Attachments > 0
+}
+
+func (hasAttachmentNode) sql() (string, []interface{}, bool) {
+	return `EXISTS (
+		SELECT 1 FROM ZICCLOUDSYNCINGOBJECT as attachments
+		WHERE (attachments.ZNOTE = ZICCLOUDSYNCINGOBJECT.Z_PK OR attachments.ZNOTE1 = ZICCLOUDSYNCINGOBJECT.Z_PK)
+			AND attachments.Z_ENT IN (
+				SELECT Z_ENT FROM Z_PRIMARYKEY
+				WHERE Z_NAME IN ('ICAttachment', 'ICMedia', 'ICTable')
+			)
+	)`, nil, true
+}
+
+// hasLinkNode matches notes whose body contains a URL
+type hasLinkNode struct{}
+
+func (hasLinkNode) match(c Candidate) bool {
+	return strings.Contains(c.text, "http://") || strings.Contains(c.text, "https://")
+}
+
+func (hasLinkNode) sql() (string, []interface{}, bool) {
+	return "", nil, false
+}
+
+// isNode matches pinned or locked notes
+type isNode struct{ what string }
+
+func (n isNode) match(c Candidate) bool {
+	if n.what == "locked" {
+		return c.Attributes.Locked
+	}
+	return c.Attributes.Pinned
+}
+
+func (n isNode) sql() (string, []interface{}, bool) {
+	if n.what == "locked" {
+		return "COALESCE(ZICCLOUDSYNCINGOBJECT.ZISPASSWORDPROTECTED, 0) = 1", nil, true
+	}
+	return "COALESCE(ZICCLOUDSYNCINGOBJECT.ZISPINNED, 0) = 1", nil, true
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+// escapeLike escapes the LIKE wildcards in s for use with ESCAPE '\'
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
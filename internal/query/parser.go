@@ -0,0 +1,218 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Grammar, lowest precedence first:
+//
+//	or      = and { "OR" and }
+//	and     = unary { [ "AND" ] unary }
+//	unary   = ( "NOT" | "-" ) unary | primary
+//	primary = "(" or ")" | term
+type parser struct {
+	tokens []token
+	pos    int
+	now    time.Time
+	strict bool
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.pos >= len(p.tokens) {
+		return token{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok, ok := p.peek()
+		if !ok || tok.kind != tokenOr {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok, ok := p.peek()
+		if !ok || tok.kind == tokenOr || tok.kind == tokenRightParen {
+			return left, nil
+		}
+		if tok.kind == tokenAnd {
+			p.pos++
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	tok, ok := p.peek()
+	if ok && tok.kind == tokenNot {
+		p.pos++
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	tok, ok := p.peek()
+	if !ok {
+		return nil, fmt.Errorf("unexpected end of query")
+	}
+	p.pos++
+
+	switch tok.kind {
+	case tokenLeftParen:
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		closing, ok := p.peek()
+		if !ok || closing.kind != tokenRightParen {
+			return nil, fmt.Errorf("missing ) for ( at position %d", tok.pos+1)
+		}
+		p.pos++
+		return inner, nil
+	case tokenPhrase:
+		return newTextNode(tok.text, p.strict), nil
+	case tokenWord:
+		return p.parseTerm(tok.text)
+	}
+	return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos+1)
+}
+
+// parseTerm parses a bare word or a field:value term
+func (p *parser) parseTerm(word string) (node, error) {
+	field, value, ok := strings.Cut(word, ":")
+	if !ok || field == "" {
+		return newTextNode(word, p.strict), nil
+	}
+	if value == "" {
+		return nil, fmt.Errorf("%s: missing value", field)
+	}
+
+	switch strings.ToLower(field) {
+	case "folder":
+		return newFolderNode(value, p.strict), nil
+	case "tag":
+		return tagNode{tag: value, strict: p.strict}, nil
+	case "title":
+		return newTitleNode(value, p.strict), nil
+	case "created", "modified":
+		from, to, err := parseDateRange(value, p.now)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", field, err)
+		}
+		return dateNode{field: strings.ToLower(field), from: from, to: to}, nil
+	case "size":
+		op, number := splitOperator(value)
+		n, err := strconv.Atoi(number)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("size: invalid number %q", number)
+		}
+		return sizeNode{op: op, n: n}, nil
+	case "has":
+		switch strings.ToLower(value) {
+		case "attachment", "attachments":
+			return hasAttachmentNode{}, nil
+		case "link", "links":
+			return hasLinkNode{}, nil
+		}
+		return nil, fmt.Errorf("has: unknown value %q (use attachment or link)", value)
+	case "is":
+		switch strings.ToLower(value) {
+		case "pinned":
+			return isNode{"pinned"}, nil
+		case "locked":
+			return isNode{"locked"}, nil
+		}
+		return nil, fmt.Errorf("is: unknown value %q (use pinned or locked)", value)
+	}
+	return nil, fmt.Errorf("unknown field %q (use folder, tag, title, created, modified, size, has or is; quote text containing a colon)", field)
+}
+
+// splitOperator splits a leading comparison operator from a value. Without
+// an operator, "=" is returned.
+func splitOperator(value string) (string, string) {
+	for _, op := range []string{">=", "<=", ">", "<", "="} {
+		if strings.HasPrefix(value, op) {
+			return op, strings.TrimPrefix(value, op)
+		}
+	}
+	return "=", value
+}
+
+// parseDateRange turns a date comparison into a half-open [from, to)
+// range; a zero time leaves that end open. Values are dates (YYYY-MM-DD,
+// today, yesterday) or ages (30d, 2w, 6mo, 1y): created:>2025-01-01 means
+// after that day, modified:<30d means less than 30 days ago.
+func parseDateRange(value string, now time.Time) (time.Time, time.Time, error) {
+	op, operand := splitOperator(value)
+
+	if day, ok := parseDay(operand, now); ok {
+		next := day.AddDate(0, 0, 1)
+		switch op {
+		case ">":
+			return next, time.Time{}, nil
+		case ">=":
+			return day, time.Time{}, nil
+		case "<":
+			return time.Time{}, day, nil
+		case "<=":
+			return time.Time{}, next, nil
+		}
+		return day, next, nil
+	}
+
+	age, err := ParseAge(operand)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid date or age %q (use e.g. 2025-01-01, today or 30d)", operand)
+	}
+	cutoff := now.Add(-age)
+	if op == ">" || op == ">=" {
+		return time.Time{}, cutoff, nil
+	}
+	return cutoff, time.Time{}, nil
+}
+
+// parseDay parses YYYY-MM-DD, today or yesterday as local midnight
+func parseDay(s string, now time.Time) (time.Time, bool) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch strings.ToLower(s) {
+	case "today":
+		return today, true
+	case "yesterday":
+		return today.AddDate(0, 0, -1), true
+	}
+	day, err := time.ParseInLocation("2006-01-02", s, now.Location())
+	if err != nil {
+		return time.Time{}, false
+	}
+	return day, true
+}
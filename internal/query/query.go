@@ -0,0 +1,144 @@
+// Package query parses note search queries such as
+//
+//	folder:Work tag:#incident created:>2025-01-01 -draft "exact phrase"
+//
+// into an AST that is compiled to a SQL prefilter and evaluated in Go over
+// decoded note bodies.
+package query
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fishfisher/apple-notes/internal/db"
+	"github.com/fishfisher/apple-notes/internal/textnorm"
+)
+
+// Query is a parsed search query
+type Query struct {
+	text string
+	root node
+}
+
+// Parse parses a query. Relative dates such as modified:<30d are resolved
+// against now. Text, titles, folders and tags match ignoring case and
+// accents (see package textnorm).
+func Parse(s string, now time.Time) (*Query, error) {
+	return parse(s, now, false)
+}
+
+// ParseStrict parses a query whose text, titles, folders and tags match as
+// written, ignoring only case
+func ParseStrict(s string, now time.Time) (*Query, error) {
+	return parse(s, now, true)
+}
+
+func parse(s string, now time.Time, strict bool) (*Query, error) {
+	tokens, err := lex(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query: %w", err)
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("invalid query: empty query")
+	}
+
+	p := &parser{tokens: tokens, now: now, strict: strict}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, fmt.Errorf("invalid query: %w", err)
+	}
+	if tok, ok := p.peek(); ok {
+		return nil, fmt.Errorf("invalid query: unexpected %q at position %d", tok.text, tok.pos+1)
+	}
+
+	return &Query{text: s, root: root}, nil
+}
+
+// String returns the query as written
+func (q *Query) String() string {
+	return q.text
+}
+
+// SQL returns a WHERE condition that selects a superset of the matching
+// notes, for the note queries in package db. exact reports whether the
+// condition alone decides the query, so Matches need not be called. An
+// empty condition selects all notes.
+func (q *Query) SQL() (condition string, args []interface{}, exact bool) {
+	return q.root.sql()
+}
+
+// Matches reports whether a candidate satisfies the query
+func (q *Query) Matches(c Candidate) bool {
+	return q.root.match(c)
+}
+
+// Find returns the notes matching the query, with decoded bodies, most
+// recently modified first
+func (q *Query) Find(database *db.DB) ([]db.Note, error) {
+	condition, args, _ := q.SQL()
+	notes, err := database.ListNoteBodiesWhere(condition, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notes: %w", err)
+	}
+	attrs, err := database.ListNoteAttributes()
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []db.Note
+	for _, note := range notes {
+		if q.Matches(NewCandidate(note, attrs[note.ID])) {
+			matches = append(matches, note)
+		}
+	}
+	return matches, nil
+}
+
+// Candidate is a note together with the facts queries are evaluated against
+type Candidate struct {
+	Note       db.Note
+	Attributes db.NoteAttributes
+	Tags       []string
+	BodyRunes  int
+
+	// text is the lower-cased title and body matched by text terms, and
+	// folded the same text normalized with textnorm.Fold
+	text   string
+	folded string
+}
+
+// NewCandidate builds a candidate from a note with a decoded body
+func NewCandidate(note db.Note, attrs db.NoteAttributes) Candidate {
+	return Candidate{
+		Note:       note,
+		Attributes: attrs,
+		Tags:       db.Hashtags(note.Body),
+		BodyRunes:  utf8.RuneCountInString(note.Body),
+		text:       strings.ToLower(note.Title + "\n" + note.Body),
+		folded:     textnorm.Fold(note.Title + "\n" + note.Body),
+	}
+}
+
+// HasTag reports whether the candidate has a hashtag, ignoring case,
+// accents and the leading #
+func (c Candidate) HasTag(tag string) bool {
+	return c.hasTag(tag, false)
+}
+
+// hasTag is HasTag, ignoring only case when strict
+func (c Candidate) hasTag(tag string, strict bool) bool {
+	key := textnorm.Fold
+	if strict {
+		key = strings.ToLower
+	}
+
+	want := key(strings.TrimPrefix(tag, "#"))
+	for _, t := range c.Tags {
+		if key(strings.TrimPrefix(t, "#")) == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,273 @@
+// Package related finds notes that are similar to a given note, comparing
+// the TF-IDF weighted words of their bodies by cosine similarity.
+//
+// Word counts are cached per note in the cache directory together with the
+// note's modification date, so only new and changed notes are decoded.
+// Weights depend on the whole collection and are computed on each lookup.
+package related
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/fishfisher/apple-notes/internal/db"
+	"github.com/fishfisher/apple-notes/internal/textnorm"
+	"github.com/fishfisher/apple-notes/internal/xdg"
+)
+
+// cacheVersion changes whenever Terms changes, to discard stale counts
+const cacheVersion = 1
+
+// maxSharedTerms is the number of terms reported per match
+const maxSharedTerms = 5
+
+// Cache holds the word counts of every note
+type Cache struct {
+	path    string
+	Version int              `json:"version"`
+	Notes   map[string]entry `json:"notes"`
+}
+
+type entry struct {
+	Modified int64          `json:"modified"`
+	Terms    map[string]int `json:"terms"`
+}
+
+// Match is a note similar to the one looked up
+type Match struct {
+	ID    string
+	Score float64
+	// Terms are the words contributing most to the similarity
+	Terms []string
+}
+
+// Path returns the cache location
+func Path() (string, error) {
+	dir, err := xdg.CacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "related.json"), nil
+}
+
+// Load reads the cache at path. A missing or outdated cache is empty.
+func Load(path string) (*Cache, error) {
+	cache := &Cache{path: path, Version: cacheVersion, Notes: make(map[string]entry)}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read related-notes cache: %w", err)
+	}
+
+	var stored Cache
+	if err := json.Unmarshal(data, &stored); err != nil || stored.Version != cacheVersion || stored.Notes == nil {
+		return cache, nil
+	}
+	cache.Notes = stored.Notes
+	return cache, nil
+}
+
+// Empty reports whether no notes are cached
+func (c *Cache) Empty() bool {
+	return len(c.Notes) == 0
+}
+
+// Update counts the words of the notes that are new or were modified since
+// they were cached, and drops notes that no longer exist. It returns the
+// number of notes counted.
+func (c *Cache) Update(database *db.DB, notes []db.Note) (int, error) {
+	current := make(map[string]bool, len(notes))
+	var changed []db.Note
+	for _, note := range notes {
+		current[note.ID] = true
+		if e, ok := c.Notes[note.ID]; !ok || e.Modified != note.Modified.Unix() {
+			changed = append(changed, note)
+		}
+	}
+	for id := range c.Notes {
+		if !current[id] {
+			delete(c.Notes, id)
+		}
+	}
+	if len(changed) == 0 {
+		return 0, nil
+	}
+
+	// Decoding every body in one query is faster when most notes changed
+	var bodies map[string]string
+	if len(changed) > len(notes)/2 {
+		all, err := database.ListNoteBodies("")
+		if err != nil {
+			return 0, err
+		}
+		bodies = make(map[string]string, len(all))
+		for _, note := range all {
+			bodies[note.ID] = note.Body
+		}
+	}
+
+	for _, note := range changed {
+		body, ok := bodies[note.ID]
+		if !ok {
+			text, err := database.GetNoteText(note.ID)
+			if err != nil {
+				text = note.Snippet
+			}
+			body = text
+		}
+		c.Notes[note.ID] = entry{Modified: note.Modified.Unix(), Terms: Terms(body)}
+	}
+	return len(changed), nil
+}
+
+// Save writes the cache
+func (c *Cache) Save() error {
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to encode related-notes cache: %w", err)
+	}
+
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write related-notes cache: %w", err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("failed to write related-notes cache: %w", err)
+	}
+	return nil
+}
+
+// Similar returns the notes most similar to the note with the given ID,
+// best first. Notes sharing no terms are left out. A limit of 0 returns
+// all of them.
+func (c *Cache) Similar(id string, limit int) ([]Match, error) {
+	target, ok := c.Notes[id]
+	if !ok {
+		return nil, fmt.Errorf("note %s is not in the related-notes cache", id)
+	}
+
+	// Smoothed inverse document frequency, as in most TF-IDF variants
+	df := make(map[string]int)
+	for _, e := range c.Notes {
+		for term := range e.Terms {
+			df[term]++
+		}
+	}
+	n := float64(len(c.Notes))
+	idf := func(term string) float64 {
+		return math.Log((1+n)/(1+float64(df[term]))) + 1
+	}
+
+	targetVector := weigh(target.Terms, idf)
+	var matches []Match
+	for otherID, other := range c.Notes {
+		if otherID == id {
+			continue
+		}
+		score, terms := cosine(targetVector, weigh(other.Terms, idf))
+		if score > 0 {
+			matches = append(matches, Match{ID: otherID, Score: score, Terms: terms})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].ID < matches[j].ID
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
+
+// Terms counts the words of text that carry meaning: folded for case and
+// accents, without stop words, numbers and single letters
+func Terms(text string) map[string]int {
+	words := strings.FieldsFunc(textnorm.Fold(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	terms := make(map[string]int)
+	for _, word := range words {
+		if utf8.RuneCountInString(word) < 2 || stopWords[word] || isNumber(word) {
+			continue
+		}
+		terms[word]++
+	}
+	return terms
+}
+
+// weigh returns the unit-length TF-IDF vector of term counts, with
+// sublinear term frequency so repeated words do not dominate
+func weigh(counts map[string]int, idf func(string) float64) map[string]float64 {
+	vector := make(map[string]float64, len(counts))
+	var norm float64
+	for term, count := range counts {
+		w := (1 + math.Log(float64(count))) * idf(term)
+		vector[term] = w
+		norm += w * w
+	}
+	norm = math.Sqrt(norm)
+	for term := range vector {
+		vector[term] /= norm
+	}
+	return vector
+}
+
+// cosine returns the similarity of two unit vectors and the terms that
+// contribute most to it
+func cosine(a, b map[string]float64) (float64, []string) {
+	if len(b) < len(a) {
+		a, b = b, a
+	}
+
+	type contribution struct {
+		term  string
+		value float64
+	}
+	var shared []contribution
+	var dot float64
+	for term, w := range a {
+		if v, ok := b[term]; ok {
+			dot += w * v
+			shared = append(shared, contribution{term, w * v})
+		}
+	}
+
+	sort.Slice(shared, func(i, j int) bool {
+		if shared[i].value != shared[j].value {
+			return shared[i].value > shared[j].value
+		}
+		return shared[i].term < shared[j].term
+	})
+	var terms []string
+	for i := 0; i < len(shared) && i < maxSharedTerms; i++ {
+		terms = append(terms, shared[i].term)
+	}
+	return dot, terms
+}
+
+func isNumber(word string) bool {
+	for _, r := range word {
+		if !unicode.IsNumber(r) {
+			return false
+		}
+	}
+	return true
+}
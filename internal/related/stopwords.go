@@ -0,0 +1,49 @@
+package related
+
+import (
+	"strings"
+
+	"github.com/fishfisher/apple-notes/internal/textnorm"
+)
+
+// englishStopWords and norwegianStopWords are common words that say little
+// about what a note is about. Norwegian covers bokmål and the most common
+// nynorsk forms.
+const (
+	englishStopWords = `
+a about above after again against all also am an and any are as at be
+because been before being below between both but by can could did do does
+doing down during each few for from further had has have having he her here
+hers herself him himself his how i if in into is it its itself just me more
+most my myself no nor not now of off on once only or other our ours
+ourselves out over own same she should so some such than that the their
+theirs them themselves then there these they this those through to too under
+until up very was we were what when where which while who whom why will with
+would you your yours yourself yourselves get got like one new use used via
+`
+	norwegianStopWords = `
+alle andre at av bare begge ble blei bli blir blitt både da dag de deg dei
+deim deira deires dem den denne der dere deres det dette di din disse dit
+ditt du dykk dykkar då eg ein eit eitt eller elles en enn er et ett etter
+for fordi fra før ha hadde han hans har hennar henne hennes her hjå ho hoe
+honom hoss hossen hun hva hvem hver hvilke hvilken hvis hvor hvordan hvorfor
+i ikke ikkje ingen ingi inkje inn inni ja jeg kan kom korleis korso kun
+kunne kva kvar kvarhelst kven kvi kvifor man mange me med medan meg meget
+mellom men mi min mine mitt mot mykje ned no noe noen noka noko nokon nokor
+nokre nå når og også om opp oss over på samme seg selv si sia sidan siden
+sin sine sitt sjøl skal skulle slik so som somme somt så sånn til um upp
+ut uten var vart varte ved vere verte vi vil ville vore vors vort være vært
+å
+`
+)
+
+// stopWords holds the folded forms of both lists
+var stopWords = make(map[string]bool)
+
+func init() {
+	for _, list := range []string{englishStopWords, norwegianStopWords} {
+		for _, word := range strings.Fields(list) {
+			stopWords[textnorm.Fold(word)] = true
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/fishfisher/apple-notes/internal/db"
+	"github.com/fishfisher/apple-notes/internal/query"
+	"github.com/fishfisher/apple-notes/internal/xdg"
+)
+
+// Action types
+const (
+	ActionMove   = "move"
+	ActionTag    = "tag"
+	ActionDelete = "delete"
+	ActionAppend = "append"
+)
+
+// File is the rules file format
+type File struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Rule applies its actions to every note matching all of its conditions
+type Rule struct {
+	Name       string     `json:"name"`
+	Conditions Conditions `json:"conditions"`
+	Actions    []Action   `json:"actions"`
+
+	titleRegex *regexp.Regexp
+	ages       map[string]time.Duration
+	query      *query.Query
+	// folderID is the folder condition resolved by ResolveFolders; 0 when
+	// the folder does not exist
+	folderID int64
+}
+
+// Conditions that a note must all satisfy. Unset conditions always match.
+type Conditions struct {
+	Query             string `json:"query,omitempty"`
+	Folder            string `json:"folder,omitempty"`
+	Tag               string `json:"tag,omitempty"`
+	TitleRegex        string `json:"title_regex,omitempty"`
+	CreatedOlderThan  string `json:"created_older_than,omitempty"`
+	CreatedNewerThan  string `json:"created_newer_than,omitempty"`
+	ModifiedOlderThan string `json:"modified_older_than,omitempty"`
+	ModifiedNewerThan string `json:"modified_newer_than,omitempty"`
+	MinSize           int    `json:"min_size,omitempty"`
+	MaxSize           int    `json:"max_size,omitempty"`
+	HasAttachments    *bool  `json:"has_attachments,omitempty"`
+	Pinned            *bool  `json:"pinned,omitempty"`
+}
+
+// Action is something a rule does to a matching note
+type Action struct {
+	Type   string `json:"type"`
+	Folder string `json:"folder,omitempty"` // move
+	Tag    string `json:"tag,omitempty"`    // tag
+	Text   string `json:"text,omitempty"`   // append
+}
+
+// DefaultPath returns the rules file location in the config directory
+func DefaultPath() (string, error) {
+	dir, err := xdg.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "rules.json"), nil
+}
+
+// Load reads and validates a rules file
+func Load(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("rules file not found: %s", path)
+		}
+		return nil, fmt.Errorf("failed to read rules file: %w", err)
+	}
+
+	var file File
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse rules file: %w", err)
+	}
+
+	for i := range file.Rules {
+		if err := file.Rules[i].compile(); err != nil {
+			name := file.Rules[i].Name
+			if name == "" {
+				name = fmt.Sprintf("#%d", i+1)
+			}
+			return nil, fmt.Errorf("rule %s: %w", name, err)
+		}
+	}
+
+	return file.Rules, nil
+}
+
+// ResolveFolders looks up the folder conditions of rules in the database, so
+// that "Archive/2023" matches that folder only and not every folder named
+// 2023. It must be called before Matches; a folder condition naming a folder
+// that does not exist matches no notes.
+func ResolveFolders(ruleSet []Rule, database *db.DB) error {
+	for i := range ruleSet {
+		r := &ruleSet[i]
+		if r.Conditions.Folder == "" {
+			continue
+		}
+		id, err := database.FindFolder(r.Conditions.Folder)
+		if err != nil {
+			return err
+		}
+		r.folderID = id
+	}
+	return nil
+}
+
+// compile validates the rule and prepares its regex and ages
+func (r *Rule) compile() error {
+	if len(r.Actions) == 0 {
+		return fmt.Errorf("no actions")
+	}
+	for _, a := range r.Actions {
+		switch a.Type {
+		case ActionMove:
+			if a.Folder == "" {
+				return fmt.Errorf("move action needs a folder")
+			}
+		case ActionTag:
+			if a.Tag == "" {
+				return fmt.Errorf("tag action needs a tag")
+			}
+		case ActionAppend:
+			if a.Text == "" {
+				return fmt.Errorf("append action needs text")
+			}
+		case ActionDelete:
+		default:
+			return fmt.Errorf("unknown action type %q (use move, tag, delete or append)", a.Type)
+		}
+	}
+
+	c := r.Conditions
+	if c.Query != "" {
+		q, err := query.Parse(c.Query, time.Now())
+		if err != nil {
+			return err
+		}
+		r.query = q
+	}
+	if c.TitleRegex != "" {
+		re, err := regexp.Compile(c.TitleRegex)
+		if err != nil {
+			return fmt.Errorf("invalid title_regex: %w", err)
+		}
+		r.titleRegex = re
+	}
+
+	r.ages = make(map[string]time.Duration)
+	for name, value := range map[string]string{
+		"created_older_than":  c.CreatedOlderThan,
+		"created_newer_than":  c.CreatedNewerThan,
+		"modified_older_than": c.ModifiedOlderThan,
+		"modified_newer_than": c.ModifiedNewerThan,
+	} {
+		if value == "" {
+			continue
+		}
+		age, err := query.ParseAge(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+		r.ages[name] = age
+	}
+
+	return nil
+}
+
+// Matches reports whether a candidate satisfies all of the rule's conditions
+func (r *Rule) Matches(c query.Candidate, now time.Time) bool {
+	cond := r.Conditions
+	note := c.Note
+
+	if r.query != nil && !r.query.Matches(c) {
+		return false
+	}
+	if cond.Folder != "" && (r.folderID == 0 || c.Attributes.FolderID != r.folderID) {
+		return false
+	}
+	if cond.Tag != "" && !c.HasTag(cond.Tag) {
+		return false
+	}
+	if r.titleRegex != nil && !r.titleRegex.MatchString(note.Title) {
+		return false
+	}
+	if age, ok := r.ages["created_older_than"]; ok && !note.Created.Before(now.Add(-age)) {
+		return false
+	}
+	if age, ok := r.ages["created_newer_than"]; ok && note.Created.Before(now.Add(-age)) {
+		return false
+	}
+	if age, ok := r.ages["modified_older_than"]; ok && !note.Modified.Before(now.Add(-age)) {
+		return false
+	}
+	if age, ok := r.ages["modified_newer_than"]; ok && note.Modified.Before(now.Add(-age)) {
+		return false
+	}
+	if cond.MinSize > 0 && c.BodyRunes < cond.MinSize {
+		return false
+	}
+	if cond.MaxSize > 0 && c.BodyRunes > cond.MaxSize {
+		return false
+	}
+	if cond.HasAttachments != nil && (c.Attributes.Attachments > 0) != *cond.HasAttachments {
+		return false
+	}
+	if cond.Pinned != nil && c.Attributes.Pinned != *cond.Pinned {
+		return false
+	}
+
+	return true
+}
+
+// String describes an action for previews
+func (a Action) String() string {
+	switch a.Type {
+	case ActionMove:
+		return fmt.Sprintf("move to '%s'", a.Folder)
+	case ActionTag:
+		return fmt.Sprintf("tag %s", a.Tag)
+	case ActionAppend:
+		return fmt.Sprintf("append %q", a.Text)
+	}
+	return a.Type
+}
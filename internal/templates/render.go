@@ -0,0 +1,150 @@
+package templates
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+)
+
+// DefaultDateLayout is used by {{date}} without a layout
+const DefaultDateLayout = "2006-01-02"
+
+// Funcs returns the functions available in templates:
+//
+//	{{date}} or {{date "Jan 2, 2006"}}  current date in a Go time layout
+//	{{weekday}}                         current weekday, e.g. "Monday"
+//	{{env "USER"}}                      environment variable
+func Funcs(now time.Time) template.FuncMap {
+	return template.FuncMap{
+		"date": func(layout ...string) string {
+			if len(layout) == 0 {
+				return now.Format(DefaultDateLayout)
+			}
+			return now.Format(strings.Join(layout, " "))
+		},
+		"weekday": func() string {
+			return now.Weekday().String()
+		},
+		"env": os.Getenv,
+	}
+}
+
+// parseText parses template text with the built-in functions
+func parseText(name, text string, now time.Time) (*template.Template, error) {
+	tmpl, err := template.New(name).Funcs(Funcs(now)).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template %s: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// Variables returns the names of the variables ({{.name}}) used in text,
+// sorted and without duplicates
+func Variables(text string) ([]string, error) {
+	tmpl, err := parseText("template", text, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	if tmpl.Tree != nil {
+		collectFields(tmpl.Tree.Root, seen, true)
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// collectFields records the variables referenced below node: the first
+// identifier of fields on the root dot, and of $.name anywhere. rootDot is
+// false inside range and with bodies, where dot is something else.
+func collectFields(node parse.Node, seen map[string]bool, rootDot bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			collectFields(child, seen, rootDot)
+		}
+	case *parse.ActionNode:
+		collectFields(n.Pipe, seen, rootDot)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			collectFields(cmd, seen, rootDot)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			collectFields(arg, seen, rootDot)
+		}
+	case *parse.FieldNode:
+		if rootDot && len(n.Ident) > 0 {
+			seen[n.Ident[0]] = true
+		}
+	case *parse.VariableNode:
+		if len(n.Ident) > 1 && n.Ident[0] == "$" {
+			seen[n.Ident[1]] = true
+		}
+	case *parse.ChainNode:
+		collectFields(n.Node, seen, rootDot)
+	case *parse.IfNode:
+		collectBranch(&n.BranchNode, seen, rootDot, rootDot)
+	case *parse.RangeNode:
+		collectBranch(&n.BranchNode, seen, rootDot, false)
+	case *parse.WithNode:
+		collectBranch(&n.BranchNode, seen, rootDot, false)
+	case *parse.TemplateNode:
+		collectFields(n.Pipe, seen, rootDot)
+	}
+}
+
+// collectBranch walks an if, range or with node. bodyDot tells whether dot
+// in the body is still the root; the pipeline and else branch keep the
+// outer dot.
+func collectBranch(n *parse.BranchNode, seen map[string]bool, rootDot, bodyDot bool) {
+	collectFields(n.Pipe, seen, rootDot)
+	collectFields(n.List, seen, bodyDot)
+	if n.ElseList != nil {
+		collectFields(n.ElseList, seen, rootDot)
+	}
+}
+
+// Render executes template text with the given variables. Every variable the
+// template uses must be set.
+func Render(name, text string, vars map[string]string, now time.Time) (string, error) {
+	tmpl, err := parseText(name, text, now)
+	if err != nil {
+		return "", err
+	}
+
+	var out strings.Builder
+	if err := tmpl.Execute(&out, vars); err != nil {
+		return "", fmt.Errorf("failed to render template %s: %w", name, err)
+	}
+	return out.String(), nil
+}
+
+// ParseVars parses key=value pairs as given to --var
+func ParseVars(pairs []string) (map[string]string, error) {
+	vars := make(map[string]string)
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid variable %q (use key=value)", pair)
+		}
+		vars[key] = value
+	}
+	return vars, nil
+}
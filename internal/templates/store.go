@@ -0,0 +1,250 @@
+package templates
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fishfisher/apple-notes/internal/xdg"
+)
+
+// Templates are stored one per file as <name>.tmpl under the templates
+// directory, so they can be edited directly and kept in version control.
+
+// fileExt is the extension of template files
+const fileExt = ".tmpl"
+
+// ErrNotFound is returned for templates that do not exist
+var ErrNotFound = errors.New("template not found")
+
+// Template is a named note body
+type Template struct {
+	Name string `json:"name"`
+	Body string `json:"body"`
+}
+
+// Dir returns the templates directory
+func Dir() (string, error) {
+	dir, err := xdg.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "templates"), nil
+}
+
+// Path returns the file a template is stored in
+func Path(name string) (string, error) {
+	if err := ValidateName(name); err != nil {
+		return "", err
+	}
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name+fileExt), nil
+}
+
+// ValidateName checks that a template name can be used as a file name
+func ValidateName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("template name cannot be empty")
+	}
+	if strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return fmt.Errorf("invalid template name %q: must not contain slashes or start with a dot", name)
+	}
+	return nil
+}
+
+// List returns all templates sorted by name
+func List() ([]Template, error) {
+	dir, err := ensureDir()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read templates directory: %w", err)
+	}
+
+	var list []Template
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), fileExt) {
+			continue
+		}
+		body, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template: %w", err)
+		}
+		list = append(list, Template{Name: strings.TrimSuffix(entry.Name(), fileExt), Body: string(body)})
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list, nil
+}
+
+// Get returns the template with the given name
+func Get(name string) (*Template, error) {
+	if _, err := ensureDir(); err != nil {
+		return nil, err
+	}
+	path, err := Path(name)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template: %w", err)
+	}
+	return &Template{Name: name, Body: string(body)}, nil
+}
+
+// Exists reports whether a template with the given name exists
+func Exists(name string) (bool, error) {
+	_, err := Get(name)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Save creates or replaces a template
+func Save(t Template) error {
+	if _, err := ensureDir(); err != nil {
+		return err
+	}
+	path, err := Path(t.Name)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(t.Body), 0644); err != nil {
+		return fmt.Errorf("failed to write template: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a template
+func Delete(name string) error {
+	path, err := Path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%w: %s", ErrNotFound, name)
+		}
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+	return nil
+}
+
+// Rename renames a template, refusing to overwrite an existing one
+func Rename(oldName, newName string) error {
+	oldPath, err := Path(oldName)
+	if err != nil {
+		return err
+	}
+	newPath, err := Path(newName)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(oldPath); os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", ErrNotFound, oldName)
+	}
+	if _, err := os.Stat(newPath); err == nil {
+		return fmt.Errorf("template '%s' already exists", newName)
+	}
+	if err := os.Rename(oldPath, newPath); err != nil {
+		return fmt.Errorf("failed to rename template: %w", err)
+	}
+	return nil
+}
+
+// Escape makes text safe to use as a template body by quoting any template
+// delimiters it contains
+func Escape(text string) string {
+	return strings.ReplaceAll(text, "{{", `{{"{{"}}`)
+}
+
+// ensureDir creates the templates directory, migrating templates from the
+// legacy ~/.apple-notes-templates.json file the first time
+func ensureDir() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create templates directory: %w", err)
+	}
+	if err := migrateLegacy(dir); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// migrateLegacy moves templates from ~/.apple-notes-templates.json into the
+// templates directory and renames the old file to .bak. Templates that
+// already exist in the directory are kept; names that cannot be file names
+// are sanitized, with a warning.
+func migrateLegacy(dir string) error {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	legacy := filepath.Join(home, ".apple-notes-templates.json")
+
+	data, err := os.ReadFile(legacy)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read legacy templates: %w", err)
+	}
+
+	var list []Template
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("failed to parse legacy templates %s: %w", legacy, err)
+	}
+
+	for _, t := range list {
+		if ValidateName(t.Name) != nil {
+			name := sanitizeName(t.Name)
+			fmt.Fprintf(os.Stderr, "Warning: legacy template %q is not a valid file name, migrating it as %q\n", t.Name, name)
+			t.Name = name
+		}
+		path := filepath.Join(dir, t.Name+fileExt)
+		if _, err := os.Stat(path); err == nil {
+			continue
+		}
+		// Legacy bodies were pasted verbatim, so quote anything that would
+		// now be read as a template action
+		if err := os.WriteFile(path, []byte(Escape(t.Body)), 0644); err != nil {
+			return fmt.Errorf("failed to migrate template '%s': %w", t.Name, err)
+		}
+	}
+
+	if err := os.Rename(legacy, legacy+".bak"); err != nil {
+		return fmt.Errorf("failed to retire legacy templates file: %w", err)
+	}
+	return nil
+}
+
+// sanitizeName turns a legacy template name into a valid one by replacing
+// slashes and dropping leading dots
+func sanitizeName(name string) string {
+	name = strings.NewReplacer("/", "-", `\`, "-").Replace(name)
+	name = strings.TrimLeft(strings.TrimSpace(name), ".")
+	if strings.TrimSpace(name) == "" {
+		return "untitled"
+	}
+	return name
+}
@@ -0,0 +1,41 @@
+// Package textnorm normalizes text for matching, so that "cafe" finds
+// "Café" and "møte" finds "MØTE". Fold applies a compatibility
+// decomposition (NFKD), removes diacritics and folds case.
+//
+// Letters that Unicode does not decompose, such as the Norwegian æ and ø,
+// are kept as they are; only their case is folded.
+package textnorm
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Fold returns s decomposed, without diacritics and case folded
+func Fold(s string) string {
+	if isASCII(s) {
+		return strings.ToLower(s)
+	}
+
+	// Transformers keep state, so each call builds its own; Fold is called
+	// from concurrent SQLite queries and grep workers
+	stripped, _, err := transform.String(transform.Chain(norm.NFKD, runes.Remove(runes.In(unicode.Mn))), s)
+	if err != nil {
+		stripped = s
+	}
+	return cases.Fold().String(stripped)
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,37 @@
+package xdg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// appName is the directory name used under each XDG base directory
+const appName = "apple-notes"
+
+// ConfigDir returns $XDG_CONFIG_HOME/apple-notes, defaulting to ~/.config/apple-notes
+func ConfigDir() (string, error) {
+	return baseDir("XDG_CONFIG_HOME", ".config")
+}
+
+// StateDir returns $XDG_STATE_HOME/apple-notes, defaulting to ~/.local/state/apple-notes
+func StateDir() (string, error) {
+	return baseDir("XDG_STATE_HOME", filepath.Join(".local", "state"))
+}
+
+// CacheDir returns $XDG_CACHE_HOME/apple-notes, defaulting to ~/.cache/apple-notes
+func CacheDir() (string, error) {
+	return baseDir("XDG_CACHE_HOME", ".cache")
+}
+
+func baseDir(envVar, homeRelative string) (string, error) {
+	base := os.Getenv(envVar)
+	if base == "" || !filepath.IsAbs(base) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get home directory: %w", err)
+		}
+		base = filepath.Join(home, homeRelative)
+	}
+	return filepath.Join(base, appName), nil
+}